@@ -0,0 +1,51 @@
+// Command auditverify checks the hash chain of an audit stream, including its
+// rotated and compressed backups, and reports the first broken or missing link.
+//
+//	auditverify -dir logs -file audit.log
+//
+// The HMAC key is resolved from DUCKOPS_AUDIT_CHAIN_KEY or the
+// audit_chain_key Docker secret unless -key is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SecDuckOps/shared/logger"
+	"github.com/SecDuckOps/shared/secrets"
+)
+
+func main() {
+	dir := flag.String("dir", "logs", "directory containing the log streams")
+	file := flag.String("file", "audit.log", "active file name of the chained stream")
+	key := flag.String("key", "", "HMAC key used by the logger (empty falls back to the secret chain, then plain SHA-256)")
+	flag.Parse()
+
+	if *key == "" {
+		*key = secrets.GetSecret("DUCKOPS_AUDIT_CHAIN_KEY", "audit_chain_key", "")
+	}
+
+	report, err := logger.VerifyChain(*dir, *file, []byte(*key))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	if len(report.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "no %s files found in %s\n", *file, *dir)
+		os.Exit(2)
+	}
+
+	for _, r := range report.Restarts {
+		fmt.Printf("WARN: skipped at %s:%d (seq %d): %s\n", r.File, r.Line, r.Seq, r.Reason)
+	}
+
+	if report.Break != nil {
+		fmt.Println("FAIL:", report.Break.Error())
+		fmt.Printf("verified %d entries (seq %d..%d) before the break\n", report.Entries, report.FirstSeq, report.LastSeq)
+		os.Exit(1)
+	}
+
+	fmt.Printf("OK: %d entries across %d files (seq %d..%d)\n", report.Entries, len(report.Files), report.FirstSeq, report.LastSeq)
+}
@@ -0,0 +1,128 @@
+// Command logmigrate rewrites logger calls that predate the event parameter,
+// inserting an event name inferred from the message:
+//
+//	s.log.Info(ctx, "scan created")                     // before
+//	s.log.Info(ctx, "resource_created", "scan created") // after
+//
+// Only calls whose receiver is a ports.Logger (or *logger.Logger) are
+// touched, as determined by type-checking. Calls still carrying the
+// "system_event" placeholder from earlier migrations are re-inferred.
+//
+//	logmigrate ./...            # list files that would change
+//	logmigrate -diff ./svc      # print unified diffs
+//	logmigrate -w ./...         # rewrite files in place
+//
+// Inference rules are evaluated in order; -rules loads them from JSON:
+//
+//	{"default": "system_event", "rules": [{"contains": "login", "event": "auth_login"}]}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the migrated files in place")
+	diff := flag.Bool("diff", false, "print unified diffs instead of file names")
+	rulesPath := flag.String("rules", "", "JSON file with event inference rules (defaults to the built-in table)")
+	verbose := flag.Bool("v", false, "report type-check errors")
+	flag.Parse()
+
+	rules, err := loadRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	dirs, err := expandDirs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	changed := 0
+	for _, dir := range dirs {
+		m := &Migrator{Rules: rules}
+		results, err := m.MigrateDir(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(2)
+		}
+		if *verbose {
+			for _, terr := range m.TypeErrors {
+				fmt.Fprintln(os.Stderr, "typecheck:", terr)
+			}
+		}
+
+		for _, pos := range m.Unresolved {
+			fmt.Fprintf(os.Stderr, "%s: skipped, argument types could not be resolved\n", pos)
+		}
+
+		for _, res := range results {
+			if !res.Changed() {
+				continue
+			}
+			changed++
+			switch {
+			case *diff:
+				fmt.Print(unifiedDiff(filepath.ToSlash(res.Path), string(res.Old), string(res.New)))
+			case !*write:
+				fmt.Printf("%s: %d call(s) would be migrated\n", res.Path, res.Calls)
+			}
+			if *write {
+				if err := os.WriteFile(res.Path, res.New, 0o644); err != nil {
+					fmt.Fprintln(os.Stderr, "error:", err)
+					os.Exit(2)
+				}
+				fmt.Fprintf(os.Stderr, "migrated %s (%d calls)\n", res.Path, res.Calls)
+			}
+		}
+	}
+
+	if changed == 0 {
+		fmt.Fprintln(os.Stderr, "nothing to migrate")
+	}
+}
+
+// expandDirs resolves arguments to directories; a trailing "/..." walks the
+// tree, skipping vendor, testdata and hidden directories.
+func expandDirs(args []string) ([]string, error) {
+	var dirs []string
+	for _, arg := range args {
+		root, recursive := strings.CutSuffix(arg, "/...")
+		if root == "" {
+			root = "."
+		}
+		if !recursive {
+			dirs = append(dirs, root)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
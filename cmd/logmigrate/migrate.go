@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	portsPath  = "github.com/SecDuckOps/shared/ports"
+	loggerPath = "github.com/SecDuckOps/shared/logger"
+)
+
+// Result describes the migration of one file.
+type Result struct {
+	Path string
+	Old  []byte
+	New  []byte
+	// Calls is the number of rewritten call sites.
+	Calls int
+}
+
+// Changed reports whether the file needs rewriting.
+func (r Result) Changed() bool { return r.Calls > 0 }
+
+// Migrator rewrites pre-event logger calls in a directory's Go files.
+type Migrator struct {
+	Rules Rules
+	// TypeErrors collects type-check errors; most are expected because old
+	// call sites no longer match the ports.Logger signatures.
+	TypeErrors []error
+	// Unresolved lists logger calls left untouched because the type of the
+	// argument that tells old and new style apart could not be resolved.
+	Unresolved []token.Position
+}
+
+// edit replaces src[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// MigrateDir migrates every Go file in dir, one package at a time so that
+// in-package test files and external _test packages are both type-checked.
+func (m *Migrator) MigrateDir(dir string) ([]Result, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	srcs := make(map[string][]byte)
+	packages := make(map[string][]*ast.File)
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		srcs[path] = src
+		if _, ok := packages[file.Name.Name]; !ok {
+			names = append(names, file.Name.Name)
+		}
+		packages[file.Name.Name] = append(packages[file.Name.Name], file)
+	}
+	if len(packages) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	imp, err := newExportImporter(fset, dir, packages)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, name := range names {
+		files := packages[name]
+		info := &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		}
+		conf := types.Config{
+			Importer: imp,
+			Error:    func(err error) { m.TypeErrors = append(m.TypeErrors, err) },
+		}
+		pkg, _ := conf.Check(name, fset, files, info)
+
+		for _, file := range files {
+			path := fset.Position(file.Pos()).Filename
+			res, err := m.migrateFile(fset, file, srcs[path], pkg, info)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			res.Path = path
+			results = append(results, res)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, nil
+}
+
+func (m *Migrator) migrateFile(fset *token.FileSet, file *ast.File, src []byte, pkg *types.Package, info *types.Info) (Result, error) {
+	res := Result{Old: src, New: src}
+	portsLogger := lookupPortsLogger(pkg)
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	var edits []edit
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		method := sel.Sel.Name
+		if method != "Debug" && method != "Info" && method != "ErrorErr" {
+			return true
+		}
+		if len(call.Args) < 2 || !isLoggerReceiver(info.TypeOf(sel.X), portsLogger) {
+			return true
+		}
+
+		// msgIndex is where the message sits once the call is migrated.
+		msgIndex := 2
+		if method == "ErrorErr" {
+			msgIndex = 3
+		}
+
+		if !styleResolved(method, call, info) {
+			m.Unresolved = append(m.Unresolved, fset.Position(call.Pos()))
+			return true
+		}
+		if isOldStyle(method, call, info) {
+			event := m.Rules.Default
+			if msg := msgIndex - 1; msg < len(call.Args) {
+				event = m.inferFrom(call.Args[msg])
+			}
+			pos := offset(call.Args[1].Pos())
+			edits = append(edits, edit{start: pos, end: pos, text: strconv.Quote(event) + ", "})
+			return true
+		}
+
+		// New-style call still carrying the placeholder of an earlier run.
+		lit, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING || msgIndex >= len(call.Args) {
+			return true
+		}
+		if value, err := strconv.Unquote(lit.Value); err != nil || value != m.Rules.Placeholder {
+			return true
+		}
+		if event := m.inferFrom(call.Args[msgIndex]); event != m.Rules.Placeholder {
+			edits = append(edits, edit{start: offset(lit.Pos()), end: offset(lit.End()), text: strconv.Quote(event)})
+		}
+		return true
+	})
+
+	if len(edits) == 0 {
+		return res, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return res, err
+	}
+	res.New = formatted
+	res.Calls = len(edits)
+	return res, nil
+}
+
+// inferFrom infers the event from a message argument; only string literals
+// carry enough information, anything else falls back to the default.
+func (m *Migrator) inferFrom(arg ast.Expr) string {
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return m.Rules.Default
+	}
+	msg, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return m.Rules.Default
+	}
+	return m.Rules.Infer(msg)
+}
+
+// isOldStyle reports whether a call predates the event parameter:
+// Debug/Info(ctx, msg, fields...) and ErrorErr(ctx, err, msg, fields...).
+func isOldStyle(method string, call *ast.CallExpr, info *types.Info) bool {
+	if method == "ErrorErr" {
+		return len(call.Args) >= 2 && !isString(info.TypeOf(call.Args[1]))
+	}
+	if len(call.Args) == 2 {
+		return true
+	}
+	return len(call.Args) > 2 && !isString(info.TypeOf(call.Args[2]))
+}
+
+// styleResolved reports whether the argument isOldStyle inspects has a known
+// type. On a partially type-checked package an unresolved argument could be an
+// already migrated event, so the call must not be rewritten.
+func styleResolved(method string, call *ast.CallExpr, info *types.Info) bool {
+	i := 2
+	if method == "ErrorErr" {
+		i = 1
+	} else if len(call.Args) == 2 {
+		return true
+	}
+	t := info.TypeOf(call.Args[i])
+	if t == nil {
+		return false
+	}
+	basic, ok := t.(*types.Basic)
+	return !ok || basic.Kind() != types.Invalid
+}
+
+func isString(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// isLoggerReceiver reports whether t is ports.Logger, implements it, or is
+// the concrete *logger.Logger.
+func isLoggerReceiver(t types.Type, portsLogger *types.Interface) bool {
+	if t == nil {
+		return false
+	}
+	if isNamed(t, portsPath, "Logger") {
+		return true
+	}
+	if ptr, ok := t.(*types.Pointer); ok && isNamed(ptr.Elem(), loggerPath, "Logger") {
+		return true
+	}
+	return portsLogger != nil && types.Implements(t, portsLogger)
+}
+
+func isNamed(t types.Type, path, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == path && named.Obj().Name() == name
+}
+
+// lookupPortsLogger finds the ports.Logger interface among the transitive
+// imports of pkg.
+func lookupPortsLogger(pkg *types.Package) *types.Interface {
+	if pkg == nil {
+		return nil
+	}
+	seen := make(map[*types.Package]bool)
+	queue := []*types.Package{pkg}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if p.Path() == portsPath {
+			if obj := p.Scope().Lookup("Logger"); obj != nil {
+				iface, _ := obj.Type().Underlying().(*types.Interface)
+				return iface
+			}
+			return nil
+		}
+		queue = append(queue, p.Imports()...)
+	}
+	return nil
+}
+
+// newExportImporter resolves the imports of the parsed files to compiler
+// export data via "go list -export". The package being migrated is never
+// compiled itself, since its old call sites do not type-check.
+func newExportImporter(fset *token.FileSet, dir string, packages map[string][]*ast.File) (types.Importer, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, files := range packages {
+		for _, f := range files {
+			for _, spec := range f.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				if err != nil || path == "C" || path == "unsafe" || seen[path] {
+					continue
+				}
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	exports := make(map[string]string)
+	if len(paths) > 0 {
+		args := append([]string{"list", "-e", "-export", "-deps", "-json=ImportPath,Export"}, paths...)
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		dec := json.NewDecoder(bytes.NewReader(out))
+		for {
+			var p struct{ ImportPath, Export string }
+			if err := dec.Decode(&p); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("go list: %w", err)
+			}
+			if p.Export != "" {
+				exports[p.ImportPath] = p.Export
+			}
+		}
+	}
+
+	return importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(file)
+	}), nil
+}
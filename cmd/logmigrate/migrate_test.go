@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestMigrateDir_Golden(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range cases {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			m := &Migrator{Rules: defaultRules()}
+			results, err := m.MigrateDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, res := range results {
+				golden := res.Path + ".golden"
+				if *update {
+					if err := os.WriteFile(golden, res.New, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(res.New, want) {
+					t.Errorf("%s does not match golden file:\n%s", res.Path, unifiedDiff(golden, string(want), string(res.New)))
+				}
+			}
+		})
+	}
+}
+
+func TestRules_FirstMatchWins(t *testing.T) {
+	r := defaultRules()
+	for msg, want := range map[string]string{
+		"Failed to start scanner": "operation_failed",
+		"Initializing workers":    "agent_start",
+		"Heartbeat":               "system_event",
+	} {
+		if got := r.Infer(msg); got != want {
+			t.Errorf("Infer(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	b := "a\nb\nc\nD\ne\nf\ng\nh\n"
+	got := unifiedDiff("x.go", a, b)
+	want := "--- a/x.go\n+++ b/x.go\n@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n"
+	if got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+	if unifiedDiff("x.go", a, a) != "" {
+		t.Error("identical inputs should produce no diff")
+	}
+	if !strings.Contains(unifiedDiff("x.go", "", "new\n"), "@@ -0,0 +1 @@") {
+		t.Error("expected an insertion hunk for a new file")
+	}
+}
+
+func TestMigrateDir_SkipsUnresolvedArguments(t *testing.T) {
+	m := &Migrator{Rules: defaultRules()}
+	results, err := m.MigrateDir(filepath.Join("testdata", "unresolved"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Calls != 1 {
+		t.Fatalf("only the resolvable old-style call should be rewritten: %+v", results)
+	}
+	if len(m.Unresolved) != 2 || m.Unresolved[0].Line != 11 || m.Unresolved[1].Line != 12 {
+		t.Errorf("expected both unresolved calls to be reported, got %v", m.Unresolved)
+	}
+}
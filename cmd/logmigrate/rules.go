@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Rule maps a message keyword onto an event name.
+type Rule struct {
+	Contains string `json:"contains"`
+	Event    string `json:"event"`
+}
+
+// Rules is the event inference configuration. Rules are evaluated in order
+// and the first match wins, so the result never depends on map iteration.
+type Rules struct {
+	// Placeholder is the event earlier migrations inserted when nothing
+	// better was known; calls still using it are re-inferred.
+	Placeholder string `json:"placeholder"`
+	// Default is used when no rule matches or the message is not a literal.
+	Default string `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// defaultRules reproduces the historical keyword table with failures ranked
+// first, so "failed to start" is an operation_failed rather than agent_start.
+func defaultRules() Rules {
+	return Rules{
+		Placeholder: "system_event",
+		Default:     "system_event",
+		Rules: []Rule{
+			{Contains: "fail", Event: "operation_failed"},
+			{Contains: "error", Event: "operation_failed"},
+			{Contains: "login", Event: "auth_login"},
+			{Contains: "delete", Event: "resource_deleted"},
+			{Contains: "create", Event: "resource_created"},
+			{Contains: "update", Event: "resource_updated"},
+			{Contains: "sync", Event: "config_sync_started"},
+			{Contains: "fetch", Event: "config_sync_started"},
+			{Contains: "start", Event: "agent_start"},
+			{Contains: "init", Event: "agent_start"},
+		},
+	}
+}
+
+// loadRules reads a JSON rules file; an empty path yields the defaults.
+func loadRules(path string) (Rules, error) {
+	if path == "" {
+		return defaultRules(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	rules := defaultRules()
+	rules.Rules = nil
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	for i, r := range rules.Rules {
+		if r.Contains == "" || r.Event == "" {
+			return Rules{}, fmt.Errorf("rule %d in %s needs both contains and event", i, path)
+		}
+	}
+	return rules, nil
+}
+
+// Infer returns the event for a log message.
+func (r Rules) Infer(msg string) string {
+	lower := strings.ToLower(msg)
+	for _, rule := range r.Rules {
+		if strings.Contains(lower, strings.ToLower(rule.Contains)) {
+			return rule.Event
+		}
+	}
+	return r.Default
+}
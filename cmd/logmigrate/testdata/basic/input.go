@@ -0,0 +1,39 @@
+package basic
+
+import (
+	"context"
+	"errors"
+
+	"github.com/SecDuckOps/shared/logger"
+	"github.com/SecDuckOps/shared/ports"
+)
+
+type Service struct {
+	log ports.Logger
+}
+
+// metrics has Info and Debug methods but is not a logger.
+type metrics struct{}
+
+func (metrics) Info(ctx context.Context, name string) {}
+
+func (metrics) Debug(ctx context.Context, name string, v int) {}
+
+func (s *Service) Run(ctx context.Context, concrete *logger.Logger, m metrics) {
+	s.log.Info(ctx, "Failed to start agent")
+	s.log.Info(ctx, "User login accepted", ports.Field{Key: "user", Value: "alice"})
+	s.log.Debug(ctx, "Fetching remote config")
+	s.log.ErrorErr(ctx, errors.New("boom"), "Could not delete scan")
+
+	msg := "dynamic message"
+	concrete.Info(ctx, msg)
+
+	// Placeholders from the previous script are re-inferred.
+	s.log.Info(ctx, "system_event", "Scan created")
+	s.log.Info(ctx, "system_event", "Heartbeat")
+
+	// Already migrated and not a logger: untouched.
+	s.log.Info(ctx, "agent_start", "Agent started")
+	m.Info(ctx, "requests")
+	m.Debug(ctx, "latency", 3)
+}
@@ -0,0 +1,14 @@
+package unresolved
+
+import (
+	"context"
+
+	"github.com/SecDuckOps/shared/ports"
+)
+
+// Run mixes migrated calls with identifiers the type checker cannot see.
+func Run(ctx context.Context, log ports.Logger) {
+	log.Info(ctx, "agent_start", undefinedMsg)
+	log.ErrorErr(ctx, undefinedErr, "Could not start")
+	log.Info(ctx, "Agent started")
+}
@@ -0,0 +1,107 @@
+// Command logquery searches the current and rotated (gzipped) files of a log
+// stream in time order and prints matching entries as JSON lines.
+//
+//	logquery -stream audit -since 24h -actor alice
+//	logquery -stream security -event auth_failed -where source.ip=10.0.0.1
+//	logquery -correlation 7f3c -since 2026-01-02T15:00:00Z -until 2026-01-02T16:00:00Z
+//
+// -where may be repeated and accepts path=value, path!=value, path~substring,
+// path>n and path<n over dotted field paths.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/logger/query"
+)
+
+type predicates []query.Predicate
+
+func (p *predicates) String() string { return fmt.Sprint(*p) }
+
+func (p *predicates) Set(expr string) error {
+	pred, err := query.ParsePredicate(expr)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, pred)
+	return nil
+}
+
+func main() {
+	dir := flag.String("dir", "logs", "directory containing the log streams")
+	stream := flag.String("stream", "audit", "stream to search: app, error, audit, security or a file name")
+	since := flag.String("since", "", "earliest entry time, RFC 3339 or a duration ago such as 24h")
+	until := flag.String("until", "", "latest entry time, RFC 3339 or a duration ago")
+	events := flag.String("event", "", "comma-separated event names")
+	actor := flag.String("actor", "", "audit actor ID or security source user")
+	correlation := flag.String("correlation", "", "correlation ID")
+	limit := flag.Int("limit", 0, "stop after this many matches (0 for all)")
+	verbose := flag.Bool("v", false, "print scan statistics to stderr")
+	var where predicates
+	flag.Var(&where, "where", "field predicate, may be repeated")
+	flag.Parse()
+
+	now := time.Now()
+	q := query.Query{Actor: *actor, CorrelationID: *correlation, Where: where, Limit: *limit}
+	var err error
+	if q.Since, err = parseTime(*since, now); err != nil {
+		fail(err)
+	}
+	if q.Until, err = parseTime(*until, now); err != nil {
+		fail(err)
+	}
+	if *events != "" {
+		q.Events = strings.Split(*events, ",")
+	}
+
+	filename := *stream
+	if !strings.HasSuffix(filename, ".log") {
+		filename += ".log"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	out := os.Stdout
+	stats, err := query.Scan(ctx, *dir, filename, q, func(r query.Result) error {
+		if _, err := out.Write(r.Raw); err != nil {
+			return err
+		}
+		_, err := out.Write([]byte{'\n'})
+		return err
+	})
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "scanned %d entries in %d files: %d matched, %d malformed\n",
+			stats.Scanned, stats.Files, stats.Matched, stats.Malformed)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// parseTime accepts RFC 3339 timestamps or a duration before now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or a duration such as 24h", s)
+	}
+	return t, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(2)
+}
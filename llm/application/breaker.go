@@ -0,0 +1,142 @@
+package application
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a provider circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects calls until the open timeout elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through; its outcome closes
+	// or re-opens the breaker.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig tunes the per-provider circuit breakers of failover chains.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker (default 3).
+	FailureThreshold int
+	// OpenTimeout is how long an open breaker rejects calls before a probe
+	// (default 30s).
+	OpenTimeout time.Duration
+	// CallTimeout bounds each provider call, or the wait for the first chunk
+	// of a stream; a timeout counts as a failure. Zero only uses the caller's
+	// context.
+	CallTimeout time.Duration
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 3
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	return c
+}
+
+// circuitBreaker tracks the consecutive failures of one provider.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	cfg      BreakerConfig
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func newCircuitBreaker(cfg BreakerConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg.withDefaults(), now: time.Now}
+}
+
+// allow reports whether a call may go through, moving an open breaker to
+// half-open once its timeout elapsed. In half-open, only one probe is
+// allowed at a time.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the breaker, returning the state it left.
+func (b *circuitBreaker) success() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := b.state
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+	return from
+}
+
+// failure records a failed call and returns the state transition. A failed
+// probe re-opens the breaker at once.
+func (b *circuitBreaker) failure() (from, to BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from = b.state
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	return from, b.state
+}
+
+// release ends a call whose outcome says nothing about the provider, such
+// as one cancelled by the caller, so that a half-open breaker can probe
+// again.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// current returns the state, without the open to half-open transition of
+// allow.
+func (b *circuitBreaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,307 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+var (
+	_ domain.ToolCaller      = (*FallbackLLM)(nil)
+	_ domain.ResultGenerator = (*FallbackLLM)(nil)
+)
+
+// FallbackLLM is a domain.LLM trying a chain of providers in order. A
+// provider whose circuit breaker is open is skipped; a failed call or a
+// timeout moves on to the next provider. Caller errors (invalid input,
+// cancelled context) are returned without failing over.
+type FallbackLLM struct {
+	name   string
+	links  []fallbackLink
+	cfg    BreakerConfig
+	logger ports.Logger
+}
+
+type fallbackLink struct {
+	llm     domain.LLM
+	breaker *circuitBreaker
+}
+
+// Name returns the chain name.
+func (f *FallbackLLM) Name() string {
+	return f.name
+}
+
+// Providers returns the provider names of the chain, in order.
+func (f *FallbackLLM) Providers() []string {
+	names := make([]string, len(f.links))
+	for i, link := range f.links {
+		names[i] = link.llm.Name()
+	}
+	return names
+}
+
+// Generate implements the standard LLM generate interface
+func (f *FallbackLLM) Generate(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (string, error) {
+	var out string
+	err := f.try(ctx, func(ctx context.Context, llm domain.LLM) error {
+		var err error
+		out, err = llm.Generate(ctx, messages, opts)
+		return err
+	})
+	return out, err
+}
+
+// GenerateMessage implements domain.ToolCaller. Providers without tool
+// support answer through Generate.
+func (f *FallbackLLM) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	var out domain.Message
+	err := f.try(ctx, func(ctx context.Context, llm domain.LLM) error {
+		var err error
+		if tc, ok := llm.(domain.ToolCaller); ok {
+			out, err = tc.GenerateMessage(ctx, messages, opts)
+			return err
+		}
+		out = domain.Message{Role: domain.RoleAssistant}
+		out.Content, err = llm.Generate(ctx, messages, opts)
+		return err
+	})
+	return out, err
+}
+
+// GenerateEx implements domain.ResultGenerator; Provider names the provider
+// of the chain that answered. Providers without result support answer
+// through GenerateMessage or Generate, with only Provider and Latency set.
+func (f *FallbackLLM) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	var out domain.GenerateResult
+	err := f.try(ctx, func(ctx context.Context, llm domain.LLM) error {
+		var err error
+		if rg, ok := llm.(domain.ResultGenerator); ok {
+			out, err = rg.GenerateEx(ctx, messages, opts)
+			return err
+		}
+		start := time.Now()
+		out = domain.GenerateResult{Provider: llm.Name()}
+		if tc, ok := llm.(domain.ToolCaller); ok {
+			var msg domain.Message
+			msg, err = tc.GenerateMessage(ctx, messages, opts)
+			out.Content, out.ToolCalls = msg.Content, msg.ToolCalls
+		} else {
+			out.Content, err = llm.Generate(ctx, messages, opts)
+		}
+		out.Latency = time.Since(start)
+		return err
+	})
+	return out, err
+}
+
+// GenerateJSON implements structured output enforcement with the first
+// available provider.
+func (f *FallbackLLM) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	return f.try(ctx, func(ctx context.Context, llm domain.LLM) error {
+		return llm.GenerateJSON(ctx, messages, opts, target)
+	})
+}
+
+// HealthCheck succeeds when any provider of the chain is healthy.
+func (f *FallbackLLM) HealthCheck(ctx context.Context) error {
+	var errs []error
+	for _, link := range f.links {
+		err := link.llm.HealthCheck(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.llm.Name(), err))
+	}
+	return f.exhausted(errs)
+}
+
+// Stream implements the LLM Port with streaming support. The chain fails
+// over until a provider produces its first chunk; after that it is committed
+// to that provider and later errors reach the caller.
+func (f *FallbackLLM) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	var errs []error
+	for i, link := range f.links {
+		if !f.allow(ctx, link) {
+			errs = append(errs, fmt.Errorf("%s: circuit open", link.llm.Name()))
+			continue
+		}
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		src, err := link.llm.Stream(streamCtx, messages, opts)
+		var first domain.ChatChunk
+		var ok bool
+		if err == nil {
+			first, ok, err = f.firstChunk(ctx, src)
+		}
+		if err != nil {
+			cancel()
+			if src != nil {
+				go drain(src)
+			}
+			if !f.failed(ctx, link, err) {
+				return nil, err
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", link.llm.Name(), err))
+			f.logFailover(ctx, i, err)
+			continue
+		}
+
+		out := make(chan domain.ChatChunk)
+		go f.forward(ctx, link, first, ok, src, out, cancel)
+		return out, nil
+	}
+	return nil, f.exhausted(errs)
+}
+
+// try runs call against each available provider until one succeeds.
+func (f *FallbackLLM) try(ctx context.Context, call func(ctx context.Context, llm domain.LLM) error) error {
+	var errs []error
+	for i, link := range f.links {
+		if !f.allow(ctx, link) {
+			errs = append(errs, fmt.Errorf("%s: circuit open", link.llm.Name()))
+			continue
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if f.cfg.CallTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, f.cfg.CallTimeout)
+		}
+		err := call(callCtx, link.llm)
+		cancel()
+
+		if err == nil {
+			f.succeeded(ctx, link)
+			return nil
+		}
+		if !f.failed(ctx, link, err) {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.llm.Name(), err))
+		f.logFailover(ctx, i, err)
+	}
+	return f.exhausted(errs)
+}
+
+// firstChunk waits for the first chunk of src, bounded by CallTimeout. An
+// error chunk is returned as an error so that the chain can still fail over.
+func (f *FallbackLLM) firstChunk(ctx context.Context, src <-chan domain.ChatChunk) (domain.ChatChunk, bool, error) {
+	var timeout <-chan time.Time
+	if f.cfg.CallTimeout > 0 {
+		timer := time.NewTimer(f.cfg.CallTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case chunk, ok := <-src:
+		if ok && chunk.Error != nil {
+			return chunk, ok, chunk.Error
+		}
+		return chunk, ok, nil
+	case <-timeout:
+		return domain.ChatChunk{}, false, types.Newf(types.ErrCodeAgentFailed, "no stream output within %s", f.cfg.CallTimeout)
+	case <-ctx.Done():
+		return domain.ChatChunk{}, false, ctx.Err()
+	}
+}
+
+// forward relays first and the rest of src to out, then records the outcome
+// of the stream on the provider's breaker.
+func (f *FallbackLLM) forward(ctx context.Context, link fallbackLink, first domain.ChatChunk, ok bool, src <-chan domain.ChatChunk, out chan<- domain.ChatChunk, cancel context.CancelFunc) {
+	defer close(out)
+	defer cancel()
+
+	var streamErr error
+	for chunk := first; ok; chunk, ok = <-src {
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			go drain(src)
+			link.breaker.release()
+			return
+		}
+	}
+
+	if streamErr != nil {
+		f.failed(ctx, link, streamErr)
+		return
+	}
+	f.succeeded(ctx, link)
+}
+
+// allow asks the provider's breaker for a call, logging skipped providers.
+func (f *FallbackLLM) allow(ctx context.Context, link fallbackLink) bool {
+	if link.breaker.allow() {
+		return true
+	}
+	f.info(ctx, "llm_failover", "skipping provider with open circuit",
+		ports.Field{Key: "provider", Value: link.llm.Name()})
+	return false
+}
+
+func (f *FallbackLLM) succeeded(ctx context.Context, link fallbackLink) {
+	if from := link.breaker.success(); from != BreakerClosed {
+		f.logTransition(ctx, link, from, BreakerClosed)
+	}
+}
+
+// failed records err against the provider and reports whether the chain
+// should move on. Cancellation by the caller and invalid input are not the
+// provider's fault, so they neither trip the breaker nor fail over.
+func (f *FallbackLLM) failed(ctx context.Context, link fallbackLink, err error) bool {
+	var appErr *types.AppError
+	if ctx.Err() != nil || (errors.As(err, &appErr) && appErr.Code == types.ErrCodeInvalidInput) {
+		link.breaker.release()
+		return false
+	}
+
+	if from, to := link.breaker.failure(); from != to {
+		f.logTransition(ctx, link, from, to)
+	}
+	return true
+}
+
+func (f *FallbackLLM) logFailover(ctx context.Context, failed int, err error) {
+	fields := []ports.Field{
+		{Key: "provider", Value: f.links[failed].llm.Name()},
+		{Key: "error", Value: err.Error()},
+	}
+	if failed+1 < len(f.links) {
+		fields = append(fields, ports.Field{Key: "next_provider", Value: f.links[failed+1].llm.Name()})
+	}
+	f.info(ctx, "llm_failover", "provider failed, failing over", fields...)
+}
+
+func (f *FallbackLLM) logTransition(ctx context.Context, link fallbackLink, from, to BreakerState) {
+	f.info(ctx, "llm_circuit_state", "circuit breaker changed state",
+		ports.Field{Key: "provider", Value: link.llm.Name()},
+		ports.Field{Key: "from", Value: from.String()},
+		ports.Field{Key: "to", Value: to.String()},
+	)
+}
+
+func (f *FallbackLLM) info(ctx context.Context, event, msg string, fields ...ports.Field) {
+	if f.logger == nil {
+		return
+	}
+	f.logger.Info(ctx, event, msg, append([]ports.Field{{Key: "chain", Value: f.name}}, fields...)...)
+}
+
+func (f *FallbackLLM) exhausted(errs []error) error {
+	return types.Wrapf(errors.Join(errs...), types.ErrCodeAgentFailed, "all providers of %s failed", f.name)
+}
+
+// drain discards the rest of an abandoned stream so its producer can exit.
+func drain(src <-chan domain.ChatChunk) {
+	for range src {
+	}
+}
@@ -0,0 +1,196 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/logger/logtest"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// fakeLLM answers Generate with generate and streams chunks.
+type fakeLLM struct {
+	name     string
+	generate func(ctx context.Context) (string, error)
+	chunks   []domain.ChatChunk
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeLLM) Name() string { return f.name }
+
+func (f *fakeLLM) Generate(ctx context.Context, _ []domain.Message, _ *domain.GenerateOptions) (string, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	return f.generate(ctx)
+}
+
+func (f *fakeLLM) Stream(ctx context.Context, _ []domain.Message, _ *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	ch := make(chan domain.ChatChunk)
+	go func() {
+		defer close(ch)
+		for _, c := range f.chunks {
+			select {
+			case ch <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (f *fakeLLM) HealthCheck(context.Context) error { return nil }
+
+func (f *fakeLLM) GenerateJSON(context.Context, []domain.Message, *domain.GenerateOptions, interface{}) error {
+	return nil
+}
+
+func (f *fakeLLM) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func answer(s string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) { return s, nil }
+}
+
+func fail(err error) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) { return "", err }
+}
+
+func newChain(t *testing.T, cfg BreakerConfig, llms ...domain.LLM) (*RegistryAdapter, *FallbackLLM, *logtest.Recorder) {
+	rec := logtest.New()
+	r, err := NewLLMRegistry(domain.Config{}, WithLogger(rec), WithBreakerConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, llm := range llms {
+		r.Register(llm)
+		names = append(names, llm.Name())
+	}
+	chain, err := r.RegisterFallback("primary", append(names, "missing")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, chain, rec
+}
+
+func TestFallback_FailoverAndBreaker(t *testing.T) {
+	down := &fakeLLM{name: "openai", generate: fail(errors.New("503 service unavailable"))}
+	up := &fakeLLM{name: "openrouter", generate: answer("from openrouter")}
+	r, chain, rec := newChain(t, BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}, down, up)
+
+	if got := chain.Providers(); len(got) != 2 {
+		t.Errorf("unregistered providers must be left out, got %v", got)
+	}
+	rec.AssertLogged(t, "llm_failover", logtest.Field("provider", "missing"))
+
+	for i := 0; i < 3; i++ {
+		out, err := r.Get("primary").Generate(context.Background(), nil, nil)
+		if err != nil || out != "from openrouter" {
+			t.Fatalf("call %d: unexpected result %q %v", i, out, err)
+		}
+	}
+	if down.callCount() != 2 {
+		t.Errorf("the open breaker must skip openai, got %d calls", down.callCount())
+	}
+	if res, err := chain.GenerateEx(context.Background(), nil, nil); err != nil || res.Provider != "openrouter" || res.Content != "from openrouter" {
+		t.Errorf("unexpected result %+v %v", res, err)
+	}
+	rec.AssertLogged(t, "llm_failover", logtest.Field("provider", "openai"), logtest.Field("next_provider", "openrouter"))
+	rec.AssertLogged(t, "llm_circuit_state", logtest.Field("provider", "openai"), logtest.Field("to", "open"))
+	if state, _ := r.BreakerState("openai"); state != BreakerOpen {
+		t.Errorf("unexpected state %s", state)
+	}
+
+	// After the open timeout a single probe goes through and closes it.
+	breaker := r.breakers["openai"]
+	breaker.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	down.generate = answer("from openai")
+	if out, _ := chain.Generate(context.Background(), nil, nil); out != "from openai" {
+		t.Errorf("expected the half-open probe to succeed, got %q", out)
+	}
+	rec.AssertLogged(t, "llm_circuit_state", logtest.Field("from", "half-open"), logtest.Field("to", "closed"))
+}
+
+func TestFallback_NoFailoverOnCallerErrors(t *testing.T) {
+	invalid := &fakeLLM{name: "openai", generate: fail(types.New(types.ErrCodeInvalidInput, "no messages provided"))}
+	next := &fakeLLM{name: "lmstudio", generate: answer("unused")}
+	r, chain, _ := newChain(t, BreakerConfig{FailureThreshold: 1}, invalid, next)
+
+	if _, err := chain.Generate(context.Background(), nil, nil); err == nil {
+		t.Fatal("expected the invalid input error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	invalid.generate = func(ctx context.Context) (string, error) { return "", ctx.Err() }
+	if _, err := chain.Generate(ctx, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation, got %v", err)
+	}
+
+	if next.callCount() != 0 {
+		t.Error("caller errors must not fail over")
+	}
+	if state, _ := r.BreakerState("openai"); state != BreakerClosed {
+		t.Errorf("caller errors must not trip the breaker, got %s", state)
+	}
+}
+
+func TestFallback_TimeoutFailsOver(t *testing.T) {
+	slow := &fakeLLM{name: "openai", generate: func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	fast := &fakeLLM{name: "lmstudio", generate: answer("fast")}
+	_, chain, _ := newChain(t, BreakerConfig{CallTimeout: 20 * time.Millisecond}, slow, fast)
+
+	if out, err := chain.Generate(context.Background(), nil, nil); err != nil || out != "fast" {
+		t.Errorf("unexpected result %q %v", out, err)
+	}
+
+	fast.generate = fail(errors.New("down"))
+	_, err := chain.Generate(context.Background(), nil, nil)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected an error joining every provider failure, got %v", err)
+	}
+}
+
+func TestFallback_StreamCommitsAfterFirstChunk(t *testing.T) {
+	broken := &fakeLLM{name: "openai", chunks: []domain.ChatChunk{{Error: errors.New("overloaded")}}}
+	flaky := &fakeLLM{name: "openrouter", chunks: []domain.ChatChunk{{Content: "Hel"}, {Error: errors.New("connection reset")}}}
+	spare := &fakeLLM{name: "lmstudio", chunks: []domain.ChatChunk{{Content: "unused"}}}
+	_, chain, rec := newChain(t, BreakerConfig{}, broken, flaky, spare)
+
+	ch, err := chain.Stream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	var streamErr error
+	for chunk := range ch {
+		content += chunk.Content
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+	}
+
+	if content != "Hel" || streamErr == nil {
+		t.Errorf("expected openrouter output then its error, got %q %v", content, streamErr)
+	}
+	if spare.callCount() != 0 {
+		t.Error("the stream must not switch providers after the first chunk")
+	}
+	rec.AssertLogged(t, "llm_failover", logtest.Field("provider", "openai"), logtest.Field("next_provider", "openrouter"))
+}
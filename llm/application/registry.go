@@ -1,10 +1,15 @@
 package application
 
 import (
+	"context"
+	"errors"
+	"io"
 	"sync"
 
 	"github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/shared/llm/infrastructure"
+	"github.com/SecDuckOps/shared/ports"
+	"github.com/SecDuckOps/shared/types"
 )
 
 // RegistryAdapter implements the domain.LLMRegistry interface.
@@ -12,10 +17,36 @@ type RegistryAdapter struct {
 	llms            map[string]domain.LLM
 	defaultProvider string
 	mu              sync.RWMutex
+
+	logger     ports.Logger
+	breakerCfg BreakerConfig
+	retry      *infrastructure.RetryPolicy
+	// breakers are shared by every chain using a provider.
+	breakers map[string]*circuitBreaker
+}
+
+// RegistryOption configures a RegistryAdapter.
+type RegistryOption func(*RegistryAdapter)
+
+// WithLogger logs the failover decisions and circuit breaker transitions of
+// fallback chains.
+func WithLogger(logger ports.Logger) RegistryOption {
+	return func(r *RegistryAdapter) { r.logger = logger }
+}
+
+// WithBreakerConfig tunes the circuit breakers of fallback chains.
+func WithBreakerConfig(cfg BreakerConfig) RegistryOption {
+	return func(r *RegistryAdapter) { r.breakerCfg = cfg }
+}
+
+// WithRetryPolicy wraps the providers built from config in an
+// infrastructure.RetryingLLM, retrying transient provider errors.
+func WithRetryPolicy(policy infrastructure.RetryPolicy) RegistryOption {
+	return func(r *RegistryAdapter) { r.retry = &policy }
 }
 
 // NewLLMRegistry creates a new thread-safe LLM registry with a fallback provider.
-func NewLLMRegistry(cfg domain.Config) (*RegistryAdapter, error) {
+func NewLLMRegistry(cfg domain.Config, opts ...RegistryOption) (*RegistryAdapter, error) {
 	defaultProvider := cfg.Default
 	if defaultProvider == "" {
 		defaultProvider = "default"
@@ -23,8 +54,22 @@ func NewLLMRegistry(cfg domain.Config) (*RegistryAdapter, error) {
 	r := &RegistryAdapter{
 		llms:            make(map[string]domain.LLM),
 		defaultProvider: defaultProvider,
+		breakers:        make(map[string]*circuitBreaker),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if err := r.RegisterFromConfig(cfg.Providers); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	for name, providers := range cfg.Fallbacks {
+		if _, err := r.RegisterFallback(name, providers...); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
 	}
-	r.RegisterFromConfig(cfg.Providers)
 	return r, nil
 }
 
@@ -80,27 +125,146 @@ func (r *RegistryAdapter) Default() domain.LLM {
 	return r.llms[r.defaultProvider]
 }
 
-func (r *RegistryAdapter) RegisterFromConfig(cfgs map[string]domain.ProviderConfig) {
+// RegisterFallback registers a FallbackLLM named name over the given
+// registered providers, tried in order. Providers that are not registered
+// (e.g. skipped for lack of an API key) are left out of the chain.
+func (r *RegistryAdapter) RegisterFallback(name string, providers ...string) (*FallbackLLM, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.llms[name]; ok {
+		if _, chain := existing.(*FallbackLLM); !chain {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "fallback chain %s conflicts with a provider", name)
+		}
+	}
+
+	chain := &FallbackLLM{name: name, cfg: r.breakerCfg, logger: r.logger}
+	for _, provider := range providers {
+		llm, ok := r.llms[provider]
+		if !ok {
+			chain.info(context.Background(), "llm_failover", "provider not registered, left out of chain",
+				ports.Field{Key: "provider", Value: provider})
+			continue
+		}
+		if _, nested := llm.(*FallbackLLM); nested {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "fallback chain %s cannot contain chain %s", name, provider)
+		}
+		breaker, ok := r.breakers[provider]
+		if !ok {
+			breaker = newCircuitBreaker(r.breakerCfg)
+			r.breakers[provider] = breaker
+		}
+		chain.links = append(chain.links, fallbackLink{llm: llm, breaker: breaker})
+	}
+	if len(chain.links) == 0 {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "fallback chain %s has no registered providers", name)
+	}
+
+	r.llms[name] = chain
+	return chain, nil
+}
+
+// BreakerState returns the circuit breaker state of a provider used by a
+// fallback chain.
+func (r *RegistryAdapter) BreakerState(provider string) (BreakerState, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	breaker, ok := r.breakers[provider]
+	if !ok {
+		return BreakerClosed, false
+	}
+	return breaker.current(), true
+}
+
+// RegisterFromConfig builds and registers an adapter for every configured
+// provider. Providers holding connections (Gemini) are released by Close.
+func (r *RegistryAdapter) RegisterFromConfig(cfgs map[string]domain.ProviderConfig) error {
 	for name, cfg := range cfgs {
-		if cfg.APIKey == "" && name != "lmstudio" {
-			continue // Skip if no API key provided (except for local LMStudio)
+		if cfg.APIKey == "" && name != "lmstudio" && name != "ollama" {
+			continue // Skip if no API key provided (except for local LMStudio and Ollama)
 		}
 
 		switch name {
 		case "openai":
-			r.Register(infrastructure.NewOpenAIAdapter(cfg.APIKey, cfg.Model))
+			r.registerProvider(infrastructure.NewOpenAIAdapter(cfg.APIKey, cfg.Model))
 		case "openrouter":
-			r.Register(infrastructure.NewOpenRouterAdapter(cfg.APIKey, cfg.Model))
+			r.registerProvider(infrastructure.NewOpenRouterAdapter(cfg.APIKey, cfg.Model))
+		case "anthropic":
+			r.registerProvider(infrastructure.NewAnthropicAdapter(cfg.APIKey, cfg.Model, cfg.BaseURL))
+		case "ollama":
+			r.registerProvider(infrastructure.NewOllamaAdapter(cfg.Model, cfg.BaseURL, infrastructure.OllamaOptions{
+				AutoPull: cfg.AutoPull,
+				OnPull:   r.logPull(),
+			}))
 		case "lmstudio":
-			r.Register(infrastructure.NewLMStudioAdapter(cfg.APIKey, cfg.Model, cfg.BaseURL))
+			r.registerProvider(infrastructure.NewLMStudioAdapter(cfg.APIKey, cfg.Model, cfg.BaseURL))
 		case "gemini":
-			// Gemini is handled separately in InitApp/App due to context requirement
-			continue
+			gemini, err := infrastructure.NewGeminiAdapter(context.Background(), cfg.APIKey, cfg.Model)
+			if err != nil {
+				return err
+			}
+			r.registerProvider(gemini)
 		default:
 			// Treat everything else with a BaseURL as a custom compatible provider
 			if cfg.BaseURL != "" {
-				r.Register(infrastructure.NewOpenAICompatibleAdapter(name, cfg.APIKey, cfg.Model, cfg.BaseURL))
+				r.registerProvider(infrastructure.NewOpenAICompatibleAdapter(name, cfg.APIKey, cfg.Model, cfg.BaseURL))
 			}
 		}
 	}
+	return nil
+}
+
+// logPull logs the status changes of automatic Ollama pulls, skipping the
+// byte-level progress in between.
+func (r *RegistryAdapter) logPull() func(infrastructure.OllamaPullProgress) {
+	if r.logger == nil {
+		return nil
+	}
+	var mu sync.Mutex
+	last := make(map[string]string)
+	return func(p infrastructure.OllamaPullProgress) {
+		mu.Lock()
+		changed := last[p.Model] != p.Status
+		last[p.Model] = p.Status
+		if p.Status == "success" {
+			delete(last, p.Model)
+		}
+		mu.Unlock()
+		if !changed {
+			return
+		}
+		r.logger.Info(context.Background(), "llm_model_pull", "pulling ollama model",
+			ports.Field{Key: "provider", Value: "ollama"},
+			ports.Field{Key: "model", Value: p.Model},
+			ports.Field{Key: "status", Value: p.Status},
+			ports.Field{Key: "completed", Value: p.Completed},
+			ports.Field{Key: "total", Value: p.Total},
+		)
+	}
+}
+
+// registerProvider registers a provider built from config, with retries
+// when a retry policy is set.
+func (r *RegistryAdapter) registerProvider(llm domain.LLM) {
+	if r.retry != nil {
+		llm = infrastructure.NewRetryingLLM(llm, *r.retry)
+	}
+	r.Register(llm)
+}
+
+// Close releases the providers implementing io.Closer and empties the
+// registry.
+func (r *RegistryAdapter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for name, llm := range r.llms {
+		if closer, ok := llm.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+		delete(r.llms, name)
+	}
+	return errors.Join(errs...)
 }
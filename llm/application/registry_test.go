@@ -0,0 +1,64 @@
+package application
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/llm/infrastructure"
+	"github.com/SecDuckOps/shared/logger/logtest"
+)
+
+func TestRegistry_FromConfigAndClose(t *testing.T) {
+	r, err := NewLLMRegistry(domain.Config{
+		Default: "gemini",
+		Providers: map[string]domain.ProviderConfig{
+			"gemini":    {APIKey: "key"},
+			"anthropic": {APIKey: "key"},
+			"ollama":    {},
+			"openai":    {}, // skipped without an API key
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := r.List()
+	sort.Strings(names)
+	if strings.Join(names, ",") != "anthropic,gemini,ollama" {
+		t.Errorf("unexpected providers %v", names)
+	}
+	if r.Default() == nil || r.Default().Name() != "gemini" {
+		t.Errorf("unexpected default %v", r.Default())
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.List()) != 0 {
+		t.Errorf("close must empty the registry, got %v", r.List())
+	}
+}
+
+func TestRegistry_LogsPullStatusChanges(t *testing.T) {
+	rec := logtest.New()
+	r, err := NewLLMRegistry(domain.Config{}, WithLogger(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	onPull := r.logPull()
+	for _, p := range []infrastructure.OllamaPullProgress{
+		{Model: "qwen2.5", Status: "pulling manifest"},
+		{Model: "qwen2.5", Status: "downloading", Total: 100, Completed: 10},
+		{Model: "qwen2.5", Status: "downloading", Total: 100, Completed: 90},
+		{Model: "qwen2.5", Status: "success"},
+	} {
+		onPull(p)
+	}
+
+	if n := len(rec.Find("llm_model_pull")); n != 3 {
+		t.Errorf("expected one entry per status, got %d", n)
+	}
+	rec.AssertLogged(t, "llm_model_pull", logtest.Field("model", "qwen2.5"), logtest.Field("status", "success"))
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SafetyRating is a provider's assessment of one harm category of a prompt
+// or response.
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// BlockedError reports a prompt or response refused by the provider's safety
+// filters. Reason is the provider's block or finish reason (e.g. "SAFETY",
+// "RECITATION").
+type BlockedError struct {
+	Provider string
+	// Prompt is set when the prompt itself was blocked.
+	Prompt  bool
+	Reason  string
+	Ratings []SafetyRating
+}
+
+func (e *BlockedError) Error() string {
+	target := "response"
+	if e.Prompt {
+		target = "prompt"
+	}
+	msg := fmt.Sprintf("%s %s blocked: %s", e.Provider, target, e.Reason)
+	var blocked []string
+	for _, r := range e.Ratings {
+		if r.Blocked {
+			blocked = append(blocked, r.Category+"="+r.Probability)
+		}
+	}
+	if len(blocked) > 0 {
+		msg += " (" + strings.Join(blocked, ", ") + ")"
+	}
+	return msg
+}
+
+// FinishError reports a response that ended without usable content for a
+// reason other than a natural stop, such as "MAX_TOKENS".
+type FinishError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *FinishError) Error() string {
+	return fmt.Sprintf("%s response ended without content: %s", e.Provider, e.Reason)
+}
@@ -34,3 +34,16 @@ type LLMRegistry interface {
 	List() []string
 	Default() LLM
 }
+
+// ToolCaller is implemented by LLMs that support tool calling. Generate only
+// returns text, while GenerateMessage returns the whole assistant message
+// including the tool calls it requested.
+type ToolCaller interface {
+	GenerateMessage(ctx context.Context, messages []Message, opts *GenerateOptions) (Message, error)
+}
+
+// ResultGenerator is implemented by LLMs reporting the metadata of a
+// generation: token usage, finish reason, model, request ID and latency.
+type ResultGenerator interface {
+	GenerateEx(ctx context.Context, messages []Message, opts *GenerateOptions) (GenerateResult, error)
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf derives a JSON Schema from the Go type of v, typically the target
+// of GenerateJSON. Struct fields follow encoding/json: json tags rename,
+// omit ("-") or make fields optional (omitempty); pointer fields are optional
+// too, every other field is required. The enum, description, minimum and
+// maximum tags add constraints:
+//
+//	Severity string `json:"severity" enum:"low,medium,high" description:"Finding severity"`
+//	Score    int    `json:"score" minimum:"0" maximum:"10"`
+func SchemaOf(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("schema: nil target")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return schemaFor(t, make(map[reflect.Type]bool))
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType, t.Kind() == reflect.Interface:
+		// Any JSON value.
+		return &Schema{}, nil
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings cannot be described by reflection.
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string.
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := schemaFor(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("schema: unsupported map key type %s", t.Key())
+		}
+		return &Schema{Type: "object"}, nil
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		return nil, fmt.Errorf("schema: unsupported type %s", t)
+	}
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	if visiting[t] {
+		// Recursive types are cut off at the first repetition.
+		return &Schema{Type: "object"}, nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	closed := false
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &closed}
+	if err := addFields(s, t, visiting); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Embedded structs are flattened like encoding/json does.
+				if err := addFields(s, ft, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop, err := schemaFor(f.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		if desc := f.Tag.Get("description"); desc != "" {
+			prop.Description = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		if prop.Minimum, err = boundTag(f, "minimum"); err != nil {
+			return err
+		}
+		if prop.Maximum, err = boundTag(f, "maximum"); err != nil {
+			return err
+		}
+		optional := f.Type.Kind() == reflect.Ptr || hasTagOption(opts, "omitempty")
+		s.Properties[name] = prop
+		if !optional {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+func boundTag(f reflect.StructField, key string) (*float64, error) {
+	tag := f.Tag.Get(key)
+	if tag == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(tag, 64)
+	if err != nil {
+		return nil, fmt.Errorf("schema: invalid %s tag on %s: %w", key, f.Name, err)
+	}
+	return &v, nil
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaName returns a name for the schema of v usable as an OpenAI
+// json_schema name: the Go type name, or "response" for unnamed types.
+func SchemaName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "response"
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return -1
+	}, t.Name())
+	if name == "" {
+		return "response"
+	}
+	return name
+}
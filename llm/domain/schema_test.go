@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type finding struct {
+	Title    string   `json:"title" description:"Short summary"`
+	Severity string   `json:"severity" enum:"low,medium,high"`
+	Score    float64  `json:"score,omitempty"`
+	CVE      *string  `json:"cve"`
+	Tags     []string `json:"tags"`
+	Internal string   `json:"-"`
+	secret   string
+	Audit
+	Related []finding        `json:"related,omitempty"`
+	Seen    time.Time        `json:"seen"`
+	Extra   map[string]any   `json:"extra,omitempty"`
+	Raw     json.RawMessage  `json:"raw,omitempty"`
+	Counts  map[string]int64 `json:"counts,omitempty"`
+}
+
+type Audit struct {
+	Reviewer string `json:"reviewer"`
+}
+
+func TestSchemaOf_Struct(t *testing.T) {
+	s, err := SchemaOf(&finding{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := json.Marshal(s)
+	want := `{"type":"object","properties":{` +
+		`"counts":{"type":"object"},` +
+		`"cve":{"type":"string"},` +
+		`"extra":{"type":"object"},` +
+		`"raw":{},` +
+		`"related":{"type":"array","items":{"type":"object"}},` +
+		`"reviewer":{"type":"string"},` +
+		`"score":{"type":"number"},` +
+		`"seen":{"type":"string","format":"date-time"},` +
+		`"severity":{"type":"string","enum":["low","medium","high"]},` +
+		`"tags":{"type":"array","items":{"type":"string"}},` +
+		`"title":{"type":"string","description":"Short summary"}},` +
+		`"required":["title","severity","tags","reviewer","seen"],` +
+		`"additionalProperties":false}`
+	if string(raw) != want {
+		t.Errorf("unexpected schema:\n got %s\nwant %s", raw, want)
+	}
+}
+
+func TestSchemaOf_ErrorsAndNames(t *testing.T) {
+	if _, err := SchemaOf(struct{ C chan int }{}); err == nil {
+		t.Error("expected an error for channel fields")
+	}
+	if _, err := SchemaOf(nil); err == nil {
+		t.Error("expected an error for a nil target")
+	}
+	if s, err := SchemaOf(&[]finding{}); err != nil || s.Type != "array" || s.Items.Type != "object" {
+		t.Errorf("unexpected slice schema %+v %v", s, err)
+	}
+
+	for _, tc := range []struct {
+		v    interface{}
+		want string
+	}{
+		{&finding{}, "finding"},
+		{&[]Audit{}, "Audit"},
+		{&map[string]int{}, "response"},
+	} {
+		if got := SchemaName(tc.v); got != tc.want {
+			t.Errorf("SchemaName(%T) = %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := SchemaOf(&struct {
+		Findings []struct {
+			Severity string  `json:"severity" enum:"low,high"`
+			Score    float64 `json:"score" minimum:"0"`
+		} `json:"findings"`
+		Done bool `json:"done"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v interface{}
+	_ = json.Unmarshal([]byte(`{"findings":[{"severity":"low","score":1},{"severity":"mid","score":-1},{}],"done":"yes","extra":1}`), &v)
+	var got []string
+	for _, verr := range s.Validate(v) {
+		got = append(got, verr.Error())
+	}
+	want := []string{
+		`$.done: expected a boolean, got a string`,
+		`$.findings[1].score: -1 is below the minimum 0`,
+		`$.findings[1].severity: "mid" is not one of low, high`,
+		`$.findings[2]: missing required property "severity"`,
+		`$.findings[2]: missing required property "score"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
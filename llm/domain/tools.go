@@ -0,0 +1,60 @@
+package domain
+
+// Tool declares a function the model may call.
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters,omitempty"`
+}
+
+// ToolChoiceMode controls whether the model calls tools.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide (provider default).
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceNone forbids tool calls.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceRequired forces at least one tool call.
+	ToolChoiceRequired ToolChoiceMode = "required"
+)
+
+// ToolChoice selects the tool-choice mode. Setting Name forces a call to
+// that specific tool. The zero value keeps the provider default.
+type ToolChoice struct {
+	Mode ToolChoiceMode
+	Name string
+}
+
+// ToolCall is a tool invocation requested by the model. Arguments holds the
+// JSON-encoded arguments object.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a streamed fragment of the tool call at Index. ID and Name
+// are set on the first fragment of a call; Arguments fragments concatenate.
+type ToolCallDelta struct {
+	Index     int
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Schema is the JSON Schema subset understood by every provider. It
+// marshals to standard JSON Schema.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
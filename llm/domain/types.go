@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type MessageRole string
 
 const (
@@ -15,6 +17,15 @@ type Message struct {
 
 	// Optional: used for tool calling and advanced providers
 	Name string `json:"name,omitempty"`
+
+	// ToolCalls are the tool invocations requested by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a RoleTool message to the call it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// Cache marks the prompt prefix ending with this message as cacheable
+	// on providers with explicit prompt caching (Anthropic cache_control).
+	Cache bool `json:"cache,omitempty"`
 }
 
 type GenerateOptions struct {
@@ -22,21 +33,129 @@ type GenerateOptions struct {
 	MaxTokens   int
 	Temperature float32
 	TopP        float32
+
+	// Tools the model may call, and whether it must.
+	Tools      []Tool
+	ToolChoice ToolChoice
+
+	// ResponseFormat constrains the output to JSON matching a schema on
+	// providers with native structured outputs. GenerateJSON sets it.
+	ResponseFormat *ResponseFormat
+	// JSONRepairAttempts bounds how often GenerateJSON re-prompts the model
+	// with the validation errors of an invalid response (default 2, negative
+	// disables repairs).
+	JSONRepairAttempts int
+
+	// temperatureSet and topPSet mark a zero Temperature or TopP as
+	// requested rather than unset.
+	temperatureSet bool
+	topPSet        bool
+}
+
+// SetTemperature sets Temperature and marks it explicit, so that 0 asks for
+// deterministic sampling instead of the provider default.
+func (o *GenerateOptions) SetTemperature(t float32) *GenerateOptions {
+	o.Temperature = t
+	o.temperatureSet = true
+	return o
+}
+
+// SetTopP sets TopP and marks it explicit, see SetTemperature.
+func (o *GenerateOptions) SetTopP(p float32) *GenerateOptions {
+	o.TopP = p
+	o.topPSet = true
+	return o
+}
+
+// TemperatureValue reports the temperature to request and whether one was
+// given, either non-zero or through SetTemperature.
+func (o *GenerateOptions) TemperatureValue() (float32, bool) {
+	return o.Temperature, o.Temperature != 0 || o.temperatureSet
+}
+
+// TopPValue reports the top-p to request and whether one was given.
+func (o *GenerateOptions) TopPValue() (float32, bool) {
+	return o.TopP, o.TopP != 0 || o.topPSet
+}
+
+// ResponseFormat names the JSON Schema a structured response must follow.
+type ResponseFormat struct {
+	Name   string
+	Schema *Schema
 }
 
 type ChatChunk struct {
 	Content string
 	Error   error
 	Done    bool
+
+	// ToolCallDeltas carry fragments of tool calls as they are streamed.
+	ToolCallDeltas []ToolCallDelta
+	// ToolCalls holds the assembled calls on the final chunk (Done) of a
+	// stream that requested tools.
+	ToolCalls []ToolCall
+	// Usage and FinishReason are reported on the final chunk (Done) by
+	// providers that stream them.
+	Usage        *Usage
+	FinishReason FinishReason
+}
+
+// FinishReason is why generation stopped, normalised across providers.
+// Provider-specific reasons without an equivalent are passed through.
+type FinishReason string
+
+const (
+	FinishStop          FinishReason = "stop"
+	FinishLength        FinishReason = "length"
+	FinishToolCalls     FinishReason = "tool_calls"
+	FinishContentFilter FinishReason = "content_filter"
+)
+
+// GenerateResult is a generated message with the metadata of its call.
+type GenerateResult struct {
+	Content   string
+	ToolCalls []ToolCall
+
+	Usage        Usage
+	FinishReason FinishReason
+	// Provider is the adapter that answered and Model the model reported by
+	// the provider, which may differ from the one requested (e.g. OpenRouter
+	// routing).
+	Provider string
+	Model    string
+	// RequestID identifies the call for the provider's support, when known.
+	RequestID string
+	Latency   time.Duration
+}
+
+// Message returns the result as an assistant message.
+func (r GenerateResult) Message() Message {
+	return Message{Role: RoleAssistant, Content: r.Content, ToolCalls: r.ToolCalls}
+}
+
+// Usage counts the tokens of a generation. PromptTokens covers the whole
+// prompt, cached parts included.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// CacheWriteTokens were written to and CacheReadTokens served from the
+	// provider prompt cache.
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
 }
 
 type ProviderConfig struct {
 	APIKey  string
 	Model   string
 	BaseURL string
+	// AutoPull lets the ollama provider pull a missing model on first use.
+	AutoPull bool
 }
 
 type Config struct {
 	Providers map[string]ProviderConfig
 	Default   string
+	// Fallbacks declares failover chains, registered under their key and
+	// tried in order, e.g. "primary": {"openai", "openrouter", "lmstudio"}.
+	Fallbacks map[string][]string
 }
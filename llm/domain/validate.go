@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidationError is one violation of a Schema, located by a JSON path such
+// as "$.findings[2].severity".
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a decoded JSON value (as produced by json.Unmarshal into an
+// interface{}) against the schema: types, required properties, enums and
+// numeric ranges. Unknown properties are tolerated since decoding ignores
+// them.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	var errs []ValidationError
+	s.validate("$", value, &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]ValidationError) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		// encoding/json decodes null into the zero value of any type.
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %s", jsonKind(value))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if v, ok := obj[name]; ok {
+				s.Properties[name].validate(path+"."+name, v, errs)
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			fail("expected an array, got %s", jsonKind(value))
+			return
+		}
+		for i, v := range arr {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), v, errs)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("expected a string, got %s", jsonKind(value))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			fail("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			if s.Type == "integer" {
+				fail("expected an integer, got %s", jsonKind(value))
+			} else {
+				fail("expected a number, got %s", jsonKind(value))
+			}
+			return
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			fail("expected an integer, got %v", n)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("%v is below the minimum %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("%v is above the maximum %v", n, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected a boolean, got %s", jsonKind(value))
+		}
+	}
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
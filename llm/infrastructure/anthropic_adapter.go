@@ -0,0 +1,546 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+)
+
+var (
+	_ domain.ToolCaller      = (*AnthropicAdapter)(nil)
+	_ domain.ResultGenerator = (*AnthropicAdapter)(nil)
+)
+
+// AnthropicAdapter implements domain.LLM over the native Anthropic Messages
+// API.
+type AnthropicAdapter struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+// defaultAnthropicModel is used when no model is configured.
+const defaultAnthropicModel = "claude-sonnet-4-5"
+
+// NewAnthropicAdapter instantiates the Anthropic client. model defaults to
+// defaultAnthropicModel and baseURL to the public API.
+func NewAnthropicAdapter(apiKey string, model string, baseURL string) *AnthropicAdapter {
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+	if baseURL == "" {
+		baseURL = anthropicBaseURL
+	}
+
+	return &AnthropicAdapter{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		maxTokens:  4096, // The Messages API requires max_tokens
+		httpClient: &http.Client{},
+	}
+}
+
+// Name identifies this LLM port
+func (a *AnthropicAdapter) Name() string {
+	return "anthropic"
+}
+
+// Generate implements the standard LLM generate interface
+func (a *AnthropicAdapter) Generate(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (string, error) {
+	msg, err := a.GenerateMessage(ctx, messages, opts)
+	return msg.Content, err
+}
+
+// GenerateMessage implements domain.ToolCaller.
+func (a *AnthropicAdapter) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	res, err := a.GenerateEx(ctx, messages, opts)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return res.Message(), nil
+}
+
+// GenerateEx implements domain.ResultGenerator. Usage includes the tokens
+// written to and read from the prompt cache.
+func (a *AnthropicAdapter) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	req, err := a.request(messages, opts, false)
+	if err != nil {
+		return domain.GenerateResult{}, err
+	}
+
+	start := time.Now()
+	resp, err := a.post(ctx, req)
+	if err != nil {
+		return domain.GenerateResult{}, types.Wrap(err, types.ErrCodeAgentFailed, "anthropic generation failed")
+	}
+	defer resp.Body.Close()
+
+	var out anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return domain.GenerateResult{}, types.Wrap(err, types.ErrCodeAgentFailed, "invalid response received from anthropic")
+	}
+
+	// An empty content array is a valid answer, e.g. after end_turn
+	// directly following a tool result.
+	res := domain.GenerateResult{
+		Usage:        out.Usage.toDomain(),
+		FinishReason: fromAnthropicStopReason(out.StopReason),
+		Provider:     a.Name(),
+		Model:        out.Model,
+		RequestID:    resp.Header.Get("Request-Id"),
+		Latency:      time.Since(start),
+	}
+	if res.RequestID == "" {
+		res.RequestID = out.ID
+	}
+	for _, block := range out.Content {
+		switch block.Type {
+		case "text":
+			res.Content += block.Text
+		case "tool_use":
+			res.ToolCalls = append(res.ToolCalls, domain.ToolCall{ID: block.ID, Name: block.Name, Arguments: toolArguments(string(block.Input))})
+		}
+	}
+	return res, nil
+}
+
+// Stream implements the LLM Port with streaming support. The final Done
+// chunk carries the assembled tool calls and the usage.
+func (a *AnthropicAdapter) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	req, err := a.request(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.post(ctx, req)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeAgentFailed, "anthropic streaming error")
+	}
+
+	ch := make(chan domain.ChatChunk)
+	go streamAnthropic(ctx, resp.Body, ch)
+	return ch, nil
+}
+
+// HealthCheck verifies the API key and that the model exists.
+func (a *AnthropicAdapter) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/v1/models/"+a.model, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GenerateJSON implements structured output enforcement. The Messages API
+// has no response format, so the schema goes into the system prompt.
+func (a *AnthropicAdapter) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	return generateJSON(ctx, a, schemaInPrompt, messages, opts, target)
+}
+
+// request builds the Messages API request, applying every option over the
+// adapter defaults.
+func (a *AnthropicAdapter) request(messages []domain.Message, opts *domain.GenerateOptions, stream bool) (*anthropicRequest, error) {
+	system, msgs, err := toAnthropicMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, types.New(types.ErrCodeInvalidInput, "no messages provided")
+	}
+
+	req := &anthropicRequest{
+		Model:     a.model,
+		MaxTokens: a.maxTokens,
+		System:    system,
+		Messages:  msgs,
+		Stream:    stream,
+	}
+	if opts == nil {
+		return req, nil
+	}
+
+	if opts.Model != "" {
+		req.Model = opts.Model
+	}
+	if opts.MaxTokens > 0 {
+		req.MaxTokens = opts.MaxTokens
+	}
+	if t, ok := opts.TemperatureValue(); ok {
+		req.Temperature = &t
+	}
+	if p, ok := opts.TopPValue(); ok {
+		req.TopP = &p
+	}
+
+	for _, t := range opts.Tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = &domain.Schema{Type: "object", Properties: map[string]*domain.Schema{}}
+		}
+		req.Tools = append(req.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	if len(req.Tools) > 0 {
+		switch {
+		case opts.ToolChoice.Name != "":
+			req.ToolChoice = &anthropicToolChoice{Type: "tool", Name: opts.ToolChoice.Name}
+		case opts.ToolChoice.Mode == domain.ToolChoiceRequired:
+			req.ToolChoice = &anthropicToolChoice{Type: "any"}
+		case opts.ToolChoice.Mode != "":
+			req.ToolChoice = &anthropicToolChoice{Type: string(opts.ToolChoice.Mode)}
+		}
+	}
+	return req, nil
+}
+
+// post sends req to the Messages API.
+func (a *AnthropicAdapter) post(ctx context.Context, req *anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return a.do(httpReq)
+}
+
+// do authenticates and sends req, turning error statuses into an
+// *anthropicAPIError.
+func (a *AnthropicAdapter) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &anthropicAPIError{StatusCode: resp.StatusCode, Header: resp.Header}
+	var body struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(raw, &body) == nil && body.Error.Type != "" {
+		apiErr.Type, apiErr.Message = body.Error.Type, body.Error.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	return nil, apiErr
+}
+
+// anthropicAPIError is an error status returned by the Anthropic API.
+type anthropicAPIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+	Header     http.Header
+}
+
+func (e *anthropicAPIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("anthropic api status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("anthropic api status %d (%s): %s", e.StatusCode, e.Type, e.Message)
+}
+
+// toAnthropicMessages splits the system prompt off messages and maps the
+// rest to Messages API turns. Tool results travel in user turns, and
+// consecutive turns of the same role are merged since the API expects them to
+// alternate. A cached message puts cache_control on its last block.
+func toAnthropicMessages(messages []domain.Message) ([]anthropicBlock, []anthropicMessage, error) {
+	var system []anthropicBlock
+	var out []anthropicMessage
+	for _, m := range messages {
+		var role string
+		var blocks []anthropicBlock
+		switch m.Role {
+		case domain.RoleSystem:
+			if m.Content != "" {
+				system = append(system, cacheBlock(anthropicBlock{Type: "text", Text: m.Content}, m.Cache))
+			}
+			continue
+		case domain.RoleTool:
+			role = "user"
+			blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		case domain.RoleAssistant:
+			role = "assistant"
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				args := toolArguments(tc.Arguments)
+				if !json.Valid([]byte(args)) {
+					return nil, nil, types.Newf(types.ErrCodeInvalidInput, "tool call %s has invalid json arguments", tc.ID)
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: json.RawMessage(args)})
+			}
+		default:
+			role = "user"
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		blocks[len(blocks)-1] = cacheBlock(blocks[len(blocks)-1], m.Cache)
+
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+		} else {
+			out = append(out, anthropicMessage{Role: role, Content: blocks})
+		}
+	}
+	return system, out, nil
+}
+
+func cacheBlock(block anthropicBlock, cache bool) anthropicBlock {
+	if cache {
+		block.CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+	}
+	return block
+}
+
+func fromAnthropicStopReason(reason string) domain.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return domain.FinishStop
+	case "max_tokens":
+		return domain.FinishLength
+	case "tool_use":
+		return domain.FinishToolCalls
+	case "refusal":
+		return domain.FinishContentFilter
+	default:
+		return domain.FinishReason(reason)
+	}
+}
+
+// toolArguments normalises empty tool arguments to an empty object.
+func toolArguments(args string) string {
+	if strings.TrimSpace(args) == "" {
+		return "{}"
+	}
+	return args
+}
+
+// streamAnthropic forwards the server-sent events of body to ch until
+// message_stop, which yields a Done chunk with the tool calls and usage.
+func streamAnthropic(ctx context.Context, body io.ReadCloser, ch chan<- domain.ChatChunk) {
+	defer close(ch)
+	defer body.Close()
+
+	var usage domain.Usage
+	var stopReason domain.FinishReason
+	calls := make(map[int]*domain.ToolCall)
+	blockCall := make(map[int]int) // content block index -> tool call index
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+			sendChunk(ctx, ch, domain.ChatChunk{Error: types.Wrap(err, types.ErrCodeAgentFailed, "invalid anthropic stream event")})
+			return
+		}
+
+		switch ev.Type {
+		case "message_start":
+			if ev.Message != nil {
+				usage = ev.Message.Usage.toDomain()
+			}
+		case "content_block_start":
+			if ev.ContentBlock == nil {
+				continue
+			}
+			switch ev.ContentBlock.Type {
+			case "text":
+				if ev.ContentBlock.Text != "" {
+					if !sendChunk(ctx, ch, domain.ChatChunk{Content: ev.ContentBlock.Text}) {
+						return
+					}
+				}
+			case "tool_use":
+				index := len(calls)
+				blockCall[ev.Index] = index
+				calls[index] = &domain.ToolCall{ID: ev.ContentBlock.ID, Name: ev.ContentBlock.Name}
+				if !sendChunk(ctx, ch, domain.ChatChunk{ToolCallDeltas: []domain.ToolCallDelta{{Index: index, ID: ev.ContentBlock.ID, Name: ev.ContentBlock.Name}}}) {
+					return
+				}
+			}
+		case "content_block_delta":
+			switch ev.Delta.Type {
+			case "text_delta":
+				if !sendChunk(ctx, ch, domain.ChatChunk{Content: ev.Delta.Text}) {
+					return
+				}
+			case "input_json_delta":
+				index, ok := blockCall[ev.Index]
+				if !ok {
+					continue
+				}
+				calls[index].Arguments += ev.Delta.PartialJSON
+				if !sendChunk(ctx, ch, domain.ChatChunk{ToolCallDeltas: []domain.ToolCallDelta{{Index: index, Arguments: ev.Delta.PartialJSON}}}) {
+					return
+				}
+			}
+		case "message_delta":
+			if ev.Usage != nil {
+				usage.CompletionTokens = ev.Usage.OutputTokens
+			}
+			if ev.Delta.StopReason != "" {
+				stopReason = fromAnthropicStopReason(ev.Delta.StopReason)
+			}
+		case "message_stop":
+			final := domain.ChatChunk{Done: true, Usage: &usage, FinishReason: stopReason}
+			if len(calls) > 0 {
+				final.ToolCalls = assembleToolCalls(calls)
+				for i := range final.ToolCalls {
+					final.ToolCalls[i].Arguments = toolArguments(final.ToolCalls[i].Arguments)
+				}
+			}
+			sendChunk(ctx, ch, final)
+			return
+		case "error":
+			err := &anthropicAPIError{}
+			if ev.Error != nil {
+				err.Type, err.Message = ev.Error.Type, ev.Error.Message
+			}
+			sendChunk(ctx, ch, domain.ChatChunk{Error: types.Wrap(err, types.ErrCodeAgentFailed, "anthropic streaming error")})
+			return
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	sendChunk(ctx, ch, domain.ChatChunk{Error: types.Wrap(err, types.ErrCodeAgentFailed, "anthropic stream ended before message_stop")})
+}
+
+// Messages API wire types.
+
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	System      []anthropicBlock     `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	Temperature *float32             `json:"temperature,omitempty"`
+	TopP        *float32             `json:"top_p,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema *domain.Schema `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      anthropicUsage   `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// toDomain counts the cached prompt tokens in PromptTokens, which the
+// Messages API reports apart from input_tokens.
+func (u anthropicUsage) toDomain() domain.Usage {
+	return domain.Usage{
+		PromptTokens:     u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		CompletionTokens: u.OutputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+	}
+}
+
+type anthropicEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *anthropicResponse `json:"message"`
+	ContentBlock *anthropicBlock    `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
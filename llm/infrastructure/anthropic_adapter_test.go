@@ -0,0 +1,187 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// anthropicServer serves /v1/messages with handle and records the requests.
+func anthropicServer(t *testing.T, handle func(w http.ResponseWriter, req map[string]any)) (*httptest.Server, *[]map[string]any) {
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+		if r.URL.Path != "/v1/messages" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`)
+			return
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		handle(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestAnthropic_GenerateEx(t *testing.T) {
+	srv, requests := anthropicServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		w.Header().Set("request-id", "req_1")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"tool_use",
+			"content":[{"type":"text","text":"Looking it up."},{"type":"tool_use","id":"toolu_2","name":"lookup_cve","input":{"id":"CVE-2"}}],
+			"usage":{"input_tokens":120,"output_tokens":15,"cache_creation_input_tokens":100,"cache_read_input_tokens":0}}`)
+	})
+
+	llm := NewAnthropicAdapter("key", "", srv.URL)
+	res, err := llm.GenerateEx(context.Background(), []domain.Message{
+		{Role: domain.RoleSystem, Content: "You triage CVEs.", Cache: true},
+		{Role: domain.RoleUser, Content: "check CVE-1"},
+		{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "toolu_1", Name: "lookup_cve", Arguments: `{"id":"CVE-1"}`}}},
+		{Role: domain.RoleTool, ToolCallID: "toolu_1", Content: "not found"},
+		{Role: domain.RoleUser, Content: "and CVE-2?", Cache: true},
+	}, (&domain.GenerateOptions{
+		Tools:      []domain.Tool{lookupTool},
+		ToolChoice: domain.ToolChoice{Mode: domain.ToolChoiceRequired},
+	}).SetTemperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Content != "Looking it up." || len(res.ToolCalls) != 1 ||
+		res.ToolCalls[0] != (domain.ToolCall{ID: "toolu_2", Name: "lookup_cve", Arguments: `{"id":"CVE-2"}`}) {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if res.Usage != (domain.Usage{PromptTokens: 220, CompletionTokens: 15, CacheWriteTokens: 100}) {
+		t.Errorf("unexpected usage %+v", res.Usage)
+	}
+	if res.FinishReason != domain.FinishToolCalls || res.Provider != "anthropic" || res.Model != "claude" || res.RequestID != "req_1" {
+		t.Errorf("unexpected result metadata %+v", res)
+	}
+
+	raw, _ := json.Marshal((*requests)[0])
+	for _, want := range []string{
+		`"model":"claude-sonnet-4-5"`,
+		`"max_tokens":4096`,
+		`"temperature":0`,
+		`"system":[{"cache_control":{"type":"ephemeral"},"text":"You triage CVEs.","type":"text"}]`,
+		`{"content":[{"text":"check CVE-1","type":"text"}],"role":"user"}`,
+		`{"content":[{"id":"toolu_1","input":{"id":"CVE-1"},"name":"lookup_cve","type":"tool_use"}],"role":"assistant"}`,
+		// The tool result and the next prompt merge into one user turn.
+		`{"content":[{"content":"not found","tool_use_id":"toolu_1","type":"tool_result"},` +
+			`{"cache_control":{"type":"ephemeral"},"text":"and CVE-2?","type":"text"}],"role":"user"}`,
+		`"tools":[{"description":"Look up a CVE by ID","input_schema":{"properties":{"id":{"type":"string"}},"required":["id"],"type":"object"},"name":"lookup_cve"}]`,
+		`"tool_choice":{"type":"any"}`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("request missing %s:\n%s", want, raw)
+		}
+	}
+}
+
+func TestAnthropic_EmptyContent(t *testing.T) {
+	srv, _ := anthropicServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"end_turn",
+			"content":[],"usage":{"input_tokens":40,"output_tokens":2}}`)
+	})
+
+	res, err := NewAnthropicAdapter("key", "", srv.URL).GenerateEx(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "done?"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Content != "" || res.FinishReason != domain.FinishStop || res.Usage != (domain.Usage{PromptTokens: 40, CompletionTokens: 2}) {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestAnthropic_Stream(t *testing.T) {
+	srv, _ := anthropicServer(t, func(w http.ResponseWriter, req map[string]any) {
+		if req["stream"] != true {
+			t.Error("stream not requested")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range []string{
+			`{"type":"message_start","message":{"id":"msg_1","content":[],"usage":{"input_tokens":50,"output_tokens":1,"cache_read_input_tokens":40}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup_cve","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"id\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"CVE-1\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":22}}`,
+			`{"type":"message_stop"}`,
+		} {
+			var typ struct{ Type string }
+			_ = json.Unmarshal([]byte(ev), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, ev)
+		}
+	})
+
+	llm := NewAnthropicAdapter("key", "claude", srv.URL)
+	ch, err := llm.Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}},
+		&domain.GenerateOptions{Tools: []domain.Tool{lookupTool}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content string
+	var deltas int
+	var final domain.ChatChunk
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatal(chunk.Error)
+		}
+		content += chunk.Content
+		deltas += len(chunk.ToolCallDeltas)
+		if chunk.Done {
+			final = chunk
+		}
+	}
+
+	if content != "Hello" || deltas != 3 {
+		t.Errorf("unexpected stream: content %q, %d deltas", content, deltas)
+	}
+	if len(final.ToolCalls) != 1 || final.ToolCalls[0] != (domain.ToolCall{ID: "toolu_1", Name: "lookup_cve", Arguments: `{"id":"CVE-1"}`}) {
+		t.Errorf("unexpected final tool calls: %+v", final.ToolCalls)
+	}
+	if final.Usage == nil || *final.Usage != (domain.Usage{PromptTokens: 90, CompletionTokens: 22, CacheReadTokens: 40}) {
+		t.Errorf("unexpected usage %+v", final.Usage)
+	}
+	if final.FinishReason != domain.FinishToolCalls {
+		t.Errorf("unexpected finish reason %q", final.FinishReason)
+	}
+}
+
+func TestAnthropic_Errors(t *testing.T) {
+	srv, _ := anthropicServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	})
+	llm := NewAnthropicAdapter("key", "claude", srv.URL)
+
+	_, err := llm.Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+	var apiErr *anthropicAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := llm.HealthCheck(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected health check error %v", err)
+	}
+	if _, err := llm.Generate(context.Background(), []domain.Message{{Role: domain.RoleSystem, Content: "only a system prompt"}}, nil); err == nil {
+		t.Error("expected an error without user messages")
+	}
+}
@@ -2,6 +2,10 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/shared/types"
@@ -44,103 +48,106 @@ func (g *GeminiAdapter) Generate(ctx context.Context, messages []domain.Message,
 	if len(messages) == 0 {
 		return "", nil
 	}
+	msg, err := g.GenerateMessage(ctx, messages, opts)
+	return msg.Content, err
+}
 
-	modelName := g.model
-	if opts != nil && opts.Model != "" {
-		modelName = opts.Model
-	}
-
-	model := g.client.GenerativeModel(modelName)
-	cs := model.StartChat()
-
-	// Map history (all but last message)
-	if len(messages) > 1 {
-		history := messages[:len(messages)-1]
-		genaiHistory := make([]*genai.Content, len(history))
-		for i, msg := range history {
-			role := "user"
-			if msg.Role == domain.RoleAssistant {
-				role = "model"
-			}
-			genaiHistory[i] = &genai.Content{
-				Role:  role,
-				Parts: []genai.Part{genai.Text(msg.Content)},
-			}
-		}
-		cs.History = genaiHistory
+// GenerateMessage implements domain.ToolCaller. Gemini function calls carry
+// no ID, so ToolCall.ID is the function name and the call's index in the
+// response ("lookup_cve_0").
+func (g *GeminiAdapter) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	res, err := g.GenerateEx(ctx, messages, opts)
+	if err != nil {
+		return domain.Message{}, err
 	}
+	return res.Message(), nil
+}
 
-	lastMsg := messages[len(messages)-1]
-	resp, err := cs.SendMessage(ctx, genai.Text(lastMsg.Content))
+// GenerateEx implements domain.ResultGenerator. The SDK exposes no request
+// ID, so RequestID stays empty and Model is the requested model.
+func (g *GeminiAdapter) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	cs, parts, err := g.session(messages, opts)
 	if err != nil {
-		return "", types.Wrap(err, types.ErrCodeAgentFailed, "failed to generate from gemini API")
+		return domain.GenerateResult{}, err
 	}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", types.New(types.ErrCodeAgentFailed, "empty response generated from gemini")
+	start := time.Now()
+	resp, err := cs.SendMessage(ctx, parts...)
+	if err != nil {
+		return domain.GenerateResult{}, geminiError(err, "failed to generate from gemini API")
 	}
 
-	fullText := ""
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			fullText += string(text)
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		if len(resp.Candidates) > 0 {
+			if reason := resp.Candidates[0].FinishReason; reason != genai.FinishReasonStop && reason != genai.FinishReasonUnspecified {
+				return domain.GenerateResult{}, types.Wrap(&domain.FinishError{Provider: "gemini", Reason: geminiReason(reason.String(), "FinishReason")},
+					types.ErrCodeAgentFailed, "empty response generated from gemini")
+			}
 		}
+		return domain.GenerateResult{}, types.New(types.ErrCodeAgentFailed, "empty response generated from gemini")
 	}
 
-	return fullText, nil
+	text, calls := fromGenaiParts(resp.Candidates[0].Content.Parts)
+	return domain.GenerateResult{
+		Content:      text,
+		ToolCalls:    calls,
+		Usage:        fromGenaiUsage(resp.UsageMetadata),
+		FinishReason: fromGenaiFinishReason(resp.Candidates[0].FinishReason, len(calls) > 0),
+		Provider:     g.Name(),
+		Model:        g.modelName(opts),
+		Latency:      time.Since(start),
+	}, nil
 }
 
 // Stream implements the LLM Port with streaming support
 func (g *GeminiAdapter) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
-	if len(messages) == 0 {
-		return nil, types.New(types.ErrCodeInvalidInput, "no messages provided for streaming")
-	}
-
-	modelName := g.model
-	if opts != nil && opts.Model != "" {
-		modelName = opts.Model
+	cs, parts, err := g.session(messages, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	ch := make(chan domain.ChatChunk)
-	model := g.client.GenerativeModel(modelName)
-	cs := model.StartChat()
-
-	// Map history
-	if len(messages) > 1 {
-		history := messages[:len(messages)-1]
-		genaiHistory := make([]*genai.Content, len(history))
-		for i, msg := range history {
-			role := "user"
-			if msg.Role == domain.RoleAssistant {
-				role = "model"
-			}
-			genaiHistory[i] = &genai.Content{
-				Role:  role,
-				Parts: []genai.Part{genai.Text(msg.Content)},
-			}
-		}
-		cs.History = genaiHistory
-	}
-
-	lastMsg := messages[len(messages)-1]
-	iter := cs.SendMessageStream(ctx, genai.Text(lastMsg.Content))
+	iter := cs.SendMessageStream(ctx, parts...)
 
 	go func() {
 		defer close(ch)
+		// Gemini streams function calls whole, one delta per call.
+		var calls []domain.ToolCall
+		var usage domain.Usage
+		var reason genai.FinishReason
 		for {
 			resp, err := iter.Next()
 			if err == iterator.Done {
+				sendChunk(ctx, ch, domain.ChatChunk{Done: true, ToolCalls: calls, Usage: &usage, FinishReason: fromGenaiFinishReason(reason, len(calls) > 0)})
 				return
 			}
 			if err != nil {
-				ch <- domain.ChatChunk{Error: err}
+				sendChunk(ctx, ch, domain.ChatChunk{Error: geminiError(err, "gemini streaming error")})
 				return
 			}
 
-			if len(resp.Candidates) > 0 {
+			// Each response reports the usage so far; the last one is final.
+			if resp.UsageMetadata != nil {
+				usage = fromGenaiUsage(resp.UsageMetadata)
+			}
+			if len(resp.Candidates) > 0 && resp.Candidates[0].FinishReason != genai.FinishReasonUnspecified {
+				reason = resp.Candidates[0].FinishReason
+			}
+
+			if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
 				for _, part := range resp.Candidates[0].Content.Parts {
-					if text, ok := part.(genai.Text); ok {
-						ch <- domain.ChatChunk{Content: string(text)}
+					switch p := part.(type) {
+					case genai.Text:
+						if !sendChunk(ctx, ch, domain.ChatChunk{Content: string(p)}) {
+							return
+						}
+					case genai.FunctionCall:
+						call := fromGenaiFunctionCall(p, len(calls))
+						delta := domain.ToolCallDelta{Index: len(calls), ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+						calls = append(calls, call)
+						if !sendChunk(ctx, ch, domain.ChatChunk{ToolCallDeltas: []domain.ToolCallDelta{delta}}) {
+							return
+						}
 					}
 				}
 			}
@@ -150,6 +157,34 @@ func (g *GeminiAdapter) Stream(ctx context.Context, messages []domain.Message, o
 	return ch, nil
 }
 
+// session configures the model for opts and starts a chat holding all but
+// the last message, whose parts are returned for sending.
+func (g *GeminiAdapter) session(messages []domain.Message, opts *domain.GenerateOptions) (*genai.ChatSession, []genai.Part, error) {
+	system, messages := genaiSystemInstruction(messages)
+	if len(messages) == 0 {
+		return nil, nil, types.New(types.ErrCodeInvalidInput, "no messages provided")
+	}
+
+	model := g.client.GenerativeModel(g.modelName(opts))
+	model.SystemInstruction = system
+	applyGenaiOptions(model, opts)
+	applyGenaiTools(model, opts)
+	applyGenaiResponseFormat(model, opts)
+	cs := model.StartChat()
+
+	contents := toGenaiContents(messages)
+	cs.History = contents[:len(contents)-1]
+	return cs, contents[len(contents)-1].Parts, nil
+}
+
+// modelName returns the model requested by opts, or the adapter default.
+func (g *GeminiAdapter) modelName(opts *domain.GenerateOptions) string {
+	if opts != nil && opts.Model != "" {
+		return opts.Model
+	}
+	return g.model
+}
+
 // HealthCheck verifies connectivity to Gemini.
 func (g *GeminiAdapter) HealthCheck(ctx context.Context) error {
 	// Simple check by listing models
@@ -161,14 +196,124 @@ func (g *GeminiAdapter) HealthCheck(ctx context.Context) error {
 	return err
 }
 
-// Close is specific to gemini adapter to clean up network connections on shutdown.
-func (g *GeminiAdapter) Close() {
+// Close is specific to gemini adapter to clean up network connections on
+// shutdown. The registry calls it through io.Closer.
+func (g *GeminiAdapter) Close() error {
 	if g.client != nil {
-		g.client.Close()
+		return g.client.Close()
 	}
+	return nil
 }
 
-// GenerateJSON implements structured output enforcement.
+// GenerateJSON implements structured output enforcement. Targets Gemini's
+// response schema cannot express get the schema in the prompt instead.
 func (g *GeminiAdapter) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
-	return generateJSON(ctx, g, messages, opts, target)
+	mode := schemaNative
+	if schema, err := domain.SchemaOf(target); err == nil && !genaiExpressible(schema) {
+		mode = schemaInPrompt
+	}
+	return generateJSON(ctx, g, mode, messages, opts, target)
+}
+
+// genaiSystemInstruction moves the system messages into a system
+// instruction, returning the remaining messages.
+func genaiSystemInstruction(messages []domain.Message) (*genai.Content, []domain.Message) {
+	var system *genai.Content
+	rest := make([]domain.Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role != domain.RoleSystem {
+			rest = append(rest, m)
+			continue
+		}
+		if system == nil {
+			system = &genai.Content{}
+		}
+		system.Parts = append(system.Parts, genai.Text(m.Content))
+	}
+	return system, rest
+}
+
+// applyGenaiOptions maps the generation options of opts to the model's
+// GenerationConfig.
+func applyGenaiOptions(model *genai.GenerativeModel, opts *domain.GenerateOptions) {
+	if opts == nil {
+		return
+	}
+	if opts.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(opts.MaxTokens))
+	}
+	if t, ok := opts.TemperatureValue(); ok {
+		model.SetTemperature(t)
+	}
+	if p, ok := opts.TopPValue(); ok {
+		model.SetTopP(p)
+	}
+}
+
+func fromGenaiUsage(u *genai.UsageMetadata) domain.Usage {
+	if u == nil {
+		return domain.Usage{}
+	}
+	return domain.Usage{
+		PromptTokens:     int(u.PromptTokenCount),
+		CompletionTokens: int(u.CandidatesTokenCount),
+		CacheReadTokens:  int(u.CachedContentTokenCount),
+	}
+}
+
+func fromGenaiFinishReason(reason genai.FinishReason, toolCalls bool) domain.FinishReason {
+	switch {
+	case toolCalls:
+		return domain.FinishToolCalls
+	case reason == genai.FinishReasonStop:
+		return domain.FinishStop
+	case reason == genai.FinishReasonMaxTokens:
+		return domain.FinishLength
+	case reason == genai.FinishReasonSafety, reason == genai.FinishReasonRecitation:
+		return domain.FinishContentFilter
+	case reason == genai.FinishReasonUnspecified:
+		return ""
+	default:
+		return domain.FinishReason(strings.ToLower(geminiReason(reason.String(), "FinishReason")))
+	}
+}
+
+// geminiError wraps err, surfacing blocked prompts and responses as a
+// *domain.BlockedError.
+func geminiError(err error, message string) error {
+	var blocked *genai.BlockedError
+	if !errors.As(err, &blocked) {
+		return types.Wrap(err, types.ErrCodeAgentFailed, message)
+	}
+
+	out := &domain.BlockedError{Provider: "gemini"}
+	var ratings []*genai.SafetyRating
+	if blocked.PromptFeedback != nil {
+		out.Prompt = true
+		out.Reason = geminiReason(blocked.PromptFeedback.BlockReason.String(), "BlockReason")
+		ratings = blocked.PromptFeedback.SafetyRatings
+	} else if blocked.Candidate != nil {
+		out.Reason = geminiReason(blocked.Candidate.FinishReason.String(), "FinishReason")
+		ratings = blocked.Candidate.SafetyRatings
+	}
+	for _, r := range ratings {
+		out.Ratings = append(out.Ratings, domain.SafetyRating{
+			Category:    geminiReason(r.Category.String(), "HarmCategory"),
+			Probability: geminiReason(r.Probability.String(), "HarmProbability"),
+			Blocked:     r.Blocked,
+		})
+	}
+	if out.Prompt {
+		return types.Wrap(out, types.ErrCodeAgentFailed, "gemini prompt blocked")
+	}
+	return types.Wrap(out, types.ErrCodeAgentFailed, "gemini response blocked")
+}
+
+var camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// geminiReason turns an SDK enum name such as "FinishReasonMaxTokens" into
+// the API form "MAX_TOKENS".
+func geminiReason(name, prefix string) string {
+	name = strings.TrimPrefix(name, prefix)
+	return strings.ToUpper(camelBoundary.ReplaceAllString(name, "${1}_${2}"))
 }
@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestGemini_SystemInstructionAndOptions(t *testing.T) {
+	system, rest := genaiSystemInstruction([]domain.Message{
+		{Role: domain.RoleSystem, Content: "You triage CVEs."},
+		{Role: domain.RoleUser, Content: "hi"},
+		{Role: domain.RoleSystem, Content: "Answer briefly."},
+	})
+	if system == nil || len(system.Parts) != 2 || system.Parts[1] != genai.Text("Answer briefly.") {
+		t.Errorf("unexpected system instruction %+v", system)
+	}
+	if len(rest) != 1 || rest[0].Role != domain.RoleUser {
+		t.Errorf("system messages must not stay in the history: %+v", rest)
+	}
+
+	model := &genai.GenerativeModel{}
+	applyGenaiOptions(model, (&domain.GenerateOptions{MaxTokens: 256, TopP: 0.8}).SetTemperature(0))
+	if model.MaxOutputTokens == nil || *model.MaxOutputTokens != 256 ||
+		model.Temperature == nil || *model.Temperature != 0 ||
+		model.TopP == nil || *model.TopP != 0.8 {
+		t.Errorf("unexpected generation config %+v", model.GenerationConfig)
+	}
+
+	model = &genai.GenerativeModel{}
+	applyGenaiOptions(model, &domain.GenerateOptions{})
+	if model.MaxOutputTokens != nil || model.Temperature != nil || model.TopP != nil {
+		t.Errorf("unset options must keep the model defaults: %+v", model.GenerationConfig)
+	}
+}
+
+func TestGemini_BlockedErrors(t *testing.T) {
+	err := geminiError(&genai.BlockedError{Candidate: &genai.Candidate{
+		FinishReason: genai.FinishReasonSafety,
+		SafetyRatings: []*genai.SafetyRating{
+			{Category: genai.HarmCategoryDangerousContent, Probability: genai.HarmProbabilityHigh, Blocked: true},
+			{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityNegligible},
+		},
+	}}, "failed")
+
+	var blocked *domain.BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected a BlockedError, got %v", err)
+	}
+	if blocked.Prompt || blocked.Reason != "SAFETY" || len(blocked.Ratings) != 2 ||
+		blocked.Ratings[0] != (domain.SafetyRating{Category: "DANGEROUS_CONTENT", Probability: "HIGH", Blocked: true}) {
+		t.Errorf("unexpected blocked error %+v", blocked)
+	}
+	if blocked.Error() != "gemini response blocked: SAFETY (DANGEROUS_CONTENT=HIGH)" {
+		t.Errorf("unexpected message %q", blocked.Error())
+	}
+
+	err = geminiError(&genai.BlockedError{PromptFeedback: &genai.PromptFeedback{BlockReason: genai.BlockReasonOther}}, "failed")
+	if !errors.As(err, &blocked) || !blocked.Prompt || blocked.Reason != "OTHER" {
+		t.Errorf("unexpected prompt block %+v", blocked)
+	}
+
+	if geminiReason(genai.FinishReasonMaxTokens.String(), "FinishReason") != "MAX_TOKENS" {
+		t.Error("unexpected finish reason name")
+	}
+	if err := geminiError(errors.New("boom"), "failed"); errors.As(err, &blocked) {
+		t.Error("plain errors must not become BlockedError")
+	}
+}
@@ -0,0 +1,185 @@
+package infrastructure
+
+import (
+	"encoding/json"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+var (
+	_ domain.ToolCaller      = (*GeminiAdapter)(nil)
+	_ domain.ResultGenerator = (*GeminiAdapter)(nil)
+)
+
+// toGenaiContents maps domain messages to Gemini contents. Assistant tool
+// calls become FunctionCall parts and tool results FunctionResponse parts.
+func toGenaiContents(messages []domain.Message) []*genai.Content {
+	contents := make([]*genai.Content, len(messages))
+	for i, msg := range messages {
+		switch {
+		case msg.Role == domain.RoleTool:
+			name := msg.Name
+			if name == "" {
+				name = toolCallName(messages[:i], msg.ToolCallID)
+			}
+			contents[i] = &genai.Content{
+				Role:  "function",
+				Parts: []genai.Part{genai.FunctionResponse{Name: name, Response: toolResponse(msg.Content)}},
+			}
+		case msg.Role == domain.RoleAssistant:
+			content := &genai.Content{Role: "model"}
+			if msg.Content != "" || len(msg.ToolCalls) == 0 {
+				content.Parts = append(content.Parts, genai.Text(msg.Content))
+			}
+			for _, tc := range msg.ToolCalls {
+				var args map[string]any
+				_ = json.Unmarshal([]byte(tc.Arguments), &args)
+				content.Parts = append(content.Parts, genai.FunctionCall{Name: tc.Name, Args: args})
+			}
+			contents[i] = content
+		default:
+			contents[i] = &genai.Content{
+				Role:  "user",
+				Parts: []genai.Part{genai.Text(msg.Content)},
+			}
+		}
+	}
+	return contents
+}
+
+// toolResponse decodes a tool result into the object Gemini expects,
+// wrapping non-object results as {"content": ...}.
+func toolResponse(content string) map[string]any {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	return map[string]any{"content": content}
+}
+
+// applyGenaiTools declares the tools and tool choice of opts on model.
+func applyGenaiTools(model *genai.GenerativeModel, opts *domain.GenerateOptions) {
+	if opts == nil || len(opts.Tools) == 0 {
+		return
+	}
+	decls := make([]*genai.FunctionDeclaration, len(opts.Tools))
+	for i, t := range opts.Tools {
+		decls[i] = &genai.FunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  toGenaiSchema(t.Parameters),
+		}
+	}
+	model.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
+
+	cfg := &genai.FunctionCallingConfig{}
+	switch {
+	case opts.ToolChoice.Name != "":
+		cfg.Mode = genai.FunctionCallingAny
+		cfg.AllowedFunctionNames = []string{opts.ToolChoice.Name}
+	case opts.ToolChoice.Mode == domain.ToolChoiceRequired:
+		cfg.Mode = genai.FunctionCallingAny
+	case opts.ToolChoice.Mode == domain.ToolChoiceNone:
+		cfg.Mode = genai.FunctionCallingNone
+	case opts.ToolChoice.Mode == domain.ToolChoiceAuto:
+		cfg.Mode = genai.FunctionCallingAuto
+	default:
+		return
+	}
+	model.ToolConfig = &genai.ToolConfig{FunctionCallingConfig: cfg}
+}
+
+// genaiFormats lists the formats Gemini accepts for each type.
+var genaiFormats = map[genai.Type]map[string]bool{
+	genai.TypeString:  {"enum": true, "date-time": true},
+	genai.TypeNumber:  {"float": true, "double": true},
+	genai.TypeInteger: {"int32": true, "int64": true},
+}
+
+// toGenaiSchema converts a JSON Schema to Gemini's OpenAPI subset. Formats
+// Gemini rejects are dropped; values of any type stay unspecified, see
+// genaiExpressible.
+func toGenaiSchema(s *domain.Schema) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	out := &genai.Schema{
+		Description: s.Description,
+		Nullable:    s.Nullable,
+		Enum:        s.Enum,
+		Items:       toGenaiSchema(s.Items),
+		Required:    s.Required,
+	}
+	switch s.Type {
+	case "string":
+		out.Type = genai.TypeString
+		if len(s.Enum) > 0 {
+			out.Format = "enum"
+		}
+	case "number":
+		out.Type = genai.TypeNumber
+	case "integer":
+		out.Type = genai.TypeInteger
+	case "boolean":
+		out.Type = genai.TypeBoolean
+	case "array":
+		out.Type = genai.TypeArray
+	case "object":
+		out.Type = genai.TypeObject
+	}
+	if out.Format == "" && genaiFormats[out.Type][s.Format] {
+		out.Format = s.Format
+	}
+	if len(s.Properties) > 0 {
+		out.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			out.Properties[name] = toGenaiSchema(prop)
+		}
+	}
+	return out
+}
+
+// genaiExpressible reports whether Gemini's schema subset can describe s:
+// every value needs a type and every object its properties, which rules out
+// values of any type and maps.
+func genaiExpressible(s *domain.Schema) bool {
+	if s == nil {
+		return true
+	}
+	if s.Type == "" || (s.Type == "object" && len(s.Properties) == 0) {
+		return false
+	}
+	for _, prop := range s.Properties {
+		if !genaiExpressible(prop) {
+			return false
+		}
+	}
+	return genaiExpressible(s.Items)
+}
+
+// fromGenaiParts collects the text and function calls of a response.
+func fromGenaiParts(parts []genai.Part) (string, []domain.ToolCall) {
+	text := ""
+	var calls []domain.ToolCall
+	for _, part := range parts {
+		switch p := part.(type) {
+		case genai.Text:
+			text += string(p)
+		case genai.FunctionCall:
+			calls = append(calls, fromGenaiFunctionCall(p, len(calls)))
+		}
+	}
+	return text, calls
+}
+
+// fromGenaiFunctionCall maps the index-th function call of a response.
+// Gemini assigns no call IDs, so the ID is derived from name and index.
+func fromGenaiFunctionCall(fc genai.FunctionCall, index int) domain.ToolCall {
+	args, err := json.Marshal(fc.Args)
+	if err != nil || fc.Args == nil {
+		args = []byte("{}")
+	}
+	return domain.ToolCall{ID: toolCallID(fc.Name, index), Name: fc.Name, Arguments: string(args)}
+}
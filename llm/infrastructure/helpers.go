@@ -3,8 +3,9 @@ package infrastructure
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"strings"
+	"reflect"
 
 	"github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/shared/types"
@@ -34,30 +35,119 @@ func newHeaderTransport(headers map[string]string, base http.RoundTripper) http.
 	}
 }
 
-// generateJSON handles structured output enforcement by stripping markdown and unmarshaling.
-func generateJSON(ctx context.Context, llm domain.LLM, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
-	resp, err := llm.Generate(ctx, messages, opts)
+// structuredOutput is how a provider is asked for JSON matching a schema.
+type structuredOutput int
+
+const (
+	// schemaInPrompt embeds the schema in a system message, for providers
+	// without native structured outputs.
+	schemaInPrompt structuredOutput = iota
+	// schemaNative passes GenerateOptions.ResponseFormat to the provider API.
+	schemaNative
+)
+
+// defaultJSONRepairAttempts is the number of re-prompts after an invalid
+// structured response when GenerateOptions.JSONRepairAttempts is zero.
+const defaultJSONRepairAttempts = 2
+
+// generateJSON handles structured output enforcement: it derives a JSON
+// Schema from target and requests it the way the provider supports. The
+// response is extracted from surrounding prose, validated against the schema
+// and unmarshaled; invalid responses are sent back with the problems found
+// until the repair attempts are exhausted.
+func generateJSON(ctx context.Context, llm domain.LLM, mode structuredOutput, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	if rv := reflect.ValueOf(target); rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return types.Newf(types.ErrCodeInvalidInput, "json target must be a non-nil pointer, got %T", target)
+	}
+	schema, err := domain.SchemaOf(target)
 	if err != nil {
-		return err
+		return types.Wrap(err, types.ErrCodeInvalidInput, "unsupported json target")
+	}
+
+	// Native structured outputs require an object at the root.
+	if schema.Type != "object" {
+		mode = schemaInPrompt
+	}
+
+	structured := domain.GenerateOptions{}
+	if opts != nil {
+		structured = *opts
+	}
+	switch mode {
+	case schemaNative:
+		structured.ResponseFormat = &domain.ResponseFormat{Name: domain.SchemaName(target), Schema: schema}
+	default:
+		messages = withSchemaPrompt(messages, schema)
+	}
+
+	repairs := structured.JSONRepairAttempts
+	if repairs == 0 {
+		repairs = defaultJSONRepairAttempts
 	}
 
-	// Clean up potential markdown formatting block
-	resp = strings.TrimSpace(resp)
-	if strings.HasPrefix(resp, "```json") {
-		resp = strings.TrimPrefix(resp, "```json")
-		resp = strings.TrimSuffix(resp, "```")
-		resp = strings.TrimSpace(resp)
-	} else if strings.HasPrefix(resp, "```") {
-		// Also handle generic code blocks if they contain JSON
-		resp = strings.TrimPrefix(resp, "```")
-		resp = strings.TrimSuffix(resp, "```")
-		resp = strings.TrimSpace(resp)
+	var attempts []JSONAttempt
+	for {
+		resp, err := llm.Generate(ctx, messages, &structured)
+		if err != nil {
+			return err
+		}
+
+		problems := decodeJSON(resp, schema, target)
+		if len(problems) == 0 {
+			return nil
+		}
+		attempts = append(attempts, JSONAttempt{Response: resp, Errors: problems})
+		if len(attempts) > repairs {
+			return types.Newf(types.ErrCodeAgentFailed, "invalid llm json response after %d attempts", len(attempts)).
+				WithContext("raw_response", resp).
+				WithContext("attempts", attempts)
+		}
+
+		messages = append(messages[:len(messages):len(messages)],
+			domain.Message{Role: domain.RoleAssistant, Content: resp},
+			repairPrompt(problems),
+		)
 	}
+}
 
-	if err := json.Unmarshal([]byte(resp), target); err != nil {
-		return types.Wrap(err, types.ErrCodeInvalidInput, "invalid llm json response").
-			WithContext("raw_response", resp)
+// withSchemaPrompt prepends a system message instructing the model to answer
+// with JSON matching schema.
+func withSchemaPrompt(messages []domain.Message, schema *domain.Schema) []domain.Message {
+	raw, _ := json.Marshal(schema)
+	prompt := domain.Message{
+		Role:    domain.RoleSystem,
+		Content: "Respond only with a JSON value, without markdown, that matches this JSON Schema:\n" + string(raw),
 	}
+	return append([]domain.Message{prompt}, messages...)
+}
 
-	return nil
+// sendChunk delivers chunk unless ctx ends first, so a stream producer does
+// not block forever on a consumer that stopped reading.
+func sendChunk(ctx context.Context, ch chan<- domain.ChatChunk, chunk domain.ChatChunk) bool {
+	select {
+	case ch <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// toolCallID derives a call ID for providers that assign none: the function
+// name and the call's index in its response, so parallel calls of the same
+// function stay distinct.
+func toolCallID(name string, index int) string {
+	return fmt.Sprintf("%s_%d", name, index)
+}
+
+// toolCallName returns the function name of the call id among the assistant
+// tool calls in history, or id itself when no call matches.
+func toolCallName(history []domain.Message, id string) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		for _, tc := range history[i].ToolCalls {
+			if tc.ID == id {
+				return tc.Name
+			}
+		}
+	}
+	return id
 }
@@ -0,0 +1,564 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+)
+
+var (
+	_ domain.ToolCaller      = (*OllamaAdapter)(nil)
+	_ domain.ResultGenerator = (*OllamaAdapter)(nil)
+)
+
+// OllamaOptions tunes the model runner of an OllamaAdapter.
+type OllamaOptions struct {
+	// KeepAlive is how long the model stays loaded after a request, as a Go
+	// duration string ("10m"; a negative one such as "-1m" keeps it loaded,
+	// "0s" unloads it at once). Empty keeps the server default.
+	KeepAlive string
+	// NumCtx sets the context window size (num_ctx) when positive.
+	NumCtx int
+	// Options are passed as Ollama model options (num_gpu, repeat_penalty,
+	// seed, ...). GenerateOptions take precedence for the fields they cover.
+	Options map[string]any
+
+	// AutoPull pulls the model when the server reports it missing, then
+	// retries the request. OnPull receives the pull progress. The pull runs
+	// on its own context bounded by PullTimeout (default 30 minutes), so a
+	// request giving up does not abort a download others wait for.
+	AutoPull    bool
+	OnPull      func(OllamaPullProgress)
+	PullTimeout time.Duration
+}
+
+// defaultOllamaPullTimeout bounds automatic pulls when PullTimeout is unset.
+const defaultOllamaPullTimeout = 30 * time.Minute
+
+// OllamaPullProgress is one progress event of a model pull.
+type OllamaPullProgress struct {
+	Model     string
+	Status    string
+	Digest    string
+	Total     int64
+	Completed int64
+}
+
+// OllamaAdapter implements domain.LLM over the native Ollama API, with model
+// management on top of chat.
+type OllamaAdapter struct {
+	baseURL    string
+	model      string
+	opts       OllamaOptions
+	httpClient *http.Client
+
+	pullMu sync.Mutex
+	pulls  map[string]*ollamaPull // automatic pulls in flight by model
+}
+
+// ollamaPull is an automatic pull that concurrent requests wait on.
+type ollamaPull struct {
+	done chan struct{}
+	err  error
+}
+
+// NewOllamaAdapter instantiates the Ollama client. baseURL defaults to the
+// local server.
+func NewOllamaAdapter(model string, baseURL string, opts OllamaOptions) *OllamaAdapter {
+	if model == "" {
+		model = "llama3.1"
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if opts.PullTimeout <= 0 {
+		opts.PullTimeout = defaultOllamaPullTimeout
+	}
+
+	return &OllamaAdapter{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		opts:       opts,
+		httpClient: &http.Client{Transport: newCaptureTransport(nil)},
+		pulls:      make(map[string]*ollamaPull),
+	}
+}
+
+// Name identifies this LLM port
+func (o *OllamaAdapter) Name() string {
+	return "ollama"
+}
+
+// Generate implements the standard LLM generate interface
+func (o *OllamaAdapter) Generate(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (string, error) {
+	msg, err := o.GenerateMessage(ctx, messages, opts)
+	return msg.Content, err
+}
+
+// GenerateMessage implements domain.ToolCaller. Ollama tool calls carry no
+// ID, so ToolCall.ID is the function name and the call's index in the
+// response ("lookup_cve_0").
+func (o *OllamaAdapter) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	res, err := o.GenerateEx(ctx, messages, opts)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return res.Message(), nil
+}
+
+// GenerateEx implements domain.ResultGenerator. Ollama assigns no request
+// IDs, so RequestID stays empty.
+func (o *OllamaAdapter) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	req, err := o.request(messages, opts, false)
+	if err != nil {
+		return domain.GenerateResult{}, err
+	}
+
+	start := time.Now()
+	resp, err := o.chat(ctx, req)
+	if err != nil {
+		return domain.GenerateResult{}, types.Wrap(err, types.ErrCodeAgentFailed, "ollama generation failed")
+	}
+	defer resp.Body.Close()
+
+	var out ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return domain.GenerateResult{}, types.Wrap(err, types.ErrCodeAgentFailed, "invalid response received from ollama")
+	}
+
+	// Empty content is a valid answer, e.g. a stop directly following a tool
+	// result.
+	calls := fromOllamaToolCalls(out.Message.ToolCalls, 0)
+	return domain.GenerateResult{
+		Content:      out.Message.Content,
+		ToolCalls:    calls,
+		Usage:        out.usage(),
+		FinishReason: fromOllamaDoneReason(out.DoneReason, len(calls) > 0),
+		Provider:     o.Name(),
+		Model:        out.Model,
+		Latency:      time.Since(start),
+	}, nil
+}
+
+// Stream implements the LLM Port with streaming support. The final Done
+// chunk carries the tool calls and the usage.
+func (o *OllamaAdapter) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	req, err := o.request(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.chat(ctx, req)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeAgentFailed, "ollama streaming error")
+	}
+
+	ch := make(chan domain.ChatChunk)
+	go streamOllama(ctx, resp.Body, ch)
+	return ch, nil
+}
+
+// HealthCheck verifies that the server answers and has the model.
+func (o *OllamaAdapter) HealthCheck(ctx context.Context) error {
+	if _, err := o.Show(ctx, o.model); err != nil {
+		return types.Wrapf(err, types.ErrCodeNotFound, "ollama model %s is not available", o.model)
+	}
+	return nil
+}
+
+// GenerateJSON implements structured output enforcement through the Ollama
+// format parameter.
+func (o *OllamaAdapter) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	return generateJSON(ctx, o, schemaNative, messages, opts, target)
+}
+
+// ListModels returns the names of the models present on the server.
+func (o *OllamaAdapter) ListModels(ctx context.Context) ([]string, error) {
+	resp, err := o.call(ctx, http.MethodGet, "/api/tags", nil)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeAgentFailed, "failed to list ollama models")
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, types.Wrap(err, types.ErrCodeAgentFailed, "invalid model list received from ollama")
+	}
+	names := make([]string, len(out.Models))
+	for i, m := range out.Models {
+		names[i] = m.Name
+	}
+	return names, nil
+}
+
+// Show returns the model details reported by /api/show: its modelfile,
+// parameters, template, details and model_info.
+func (o *OllamaAdapter) Show(ctx context.Context, model string) (map[string]any, error) {
+	resp, err := o.call(ctx, http.MethodPost, "/api/show", map[string]any{"model": model})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Pull downloads model, reporting progress to onProgress when non-nil.
+func (o *OllamaAdapter) Pull(ctx context.Context, model string, onProgress func(OllamaPullProgress)) error {
+	resp, err := o.call(ctx, http.MethodPost, "/api/pull", map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeAgentFailed, "failed to pull ollama model %s", model)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var ev struct {
+			Status    string `json:"status"`
+			Digest    string `json:"digest"`
+			Total     int64  `json:"total"`
+			Completed int64  `json:"completed"`
+			Error     string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return types.Wrap(err, types.ErrCodeAgentFailed, "invalid ollama pull event")
+		}
+		if ev.Error != "" {
+			return types.Newf(types.ErrCodeAgentFailed, "failed to pull ollama model %s: %s", model, ev.Error)
+		}
+		if onProgress != nil {
+			onProgress(OllamaPullProgress{Model: model, Status: ev.Status, Digest: ev.Digest, Total: ev.Total, Completed: ev.Completed})
+		}
+		if ev.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return types.Wrapf(err, types.ErrCodeAgentFailed, "failed to pull ollama model %s", model)
+	}
+	return types.Newf(types.ErrCodeAgentFailed, "ollama pull of %s ended without success", model)
+}
+
+// chat posts req to /api/chat. With AutoPull, a missing model is pulled and
+// the request retried once.
+func (o *OllamaAdapter) chat(ctx context.Context, req *ollamaChatRequest) (*http.Response, error) {
+	resp, err := o.call(ctx, http.MethodPost, "/api/chat", req)
+	var apiErr *ollamaAPIError
+	if !o.opts.AutoPull || !errors.As(err, &apiErr) || !apiErr.modelNotFound() {
+		return resp, err
+	}
+
+	if err := o.pullOnce(ctx, req.Model); err != nil {
+		return nil, err
+	}
+	return o.call(ctx, http.MethodPost, "/api/chat", req)
+}
+
+// pullOnce waits for the automatic pull of model, starting it unless a
+// concurrent request already did. ctx only bounds the wait.
+func (o *OllamaAdapter) pullOnce(ctx context.Context, model string) error {
+	o.pullMu.Lock()
+	p, ok := o.pulls[model]
+	if !ok {
+		p = &ollamaPull{done: make(chan struct{})}
+		o.pulls[model] = p
+		go o.runPull(model, p)
+	}
+	o.pullMu.Unlock()
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *OllamaAdapter) runPull(model string, p *ollamaPull) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.opts.PullTimeout)
+	defer cancel()
+
+	if _, err := o.Show(ctx, model); err != nil {
+		p.err = o.Pull(ctx, model, o.opts.OnPull)
+	}
+
+	o.pullMu.Lock()
+	delete(o.pulls, model)
+	o.pullMu.Unlock()
+	close(p.done)
+}
+
+// call sends a JSON request to path, turning error statuses into an
+// *ollamaAPIError.
+func (o *OllamaAdapter) call(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &ollamaAPIError{StatusCode: resp.StatusCode}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var out struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(raw, &out) == nil && out.Error != "" {
+		apiErr.Message = out.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	return nil, apiErr
+}
+
+// ollamaAPIError is an error status returned by the Ollama API.
+type ollamaAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ollamaAPIError) Error() string {
+	return fmt.Sprintf("ollama api status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *ollamaAPIError) modelNotFound() bool {
+	return e.StatusCode == http.StatusNotFound && strings.Contains(e.Message, "not found")
+}
+
+// request builds the chat request. Model options start from the adapter
+// options, then GenerateOptions override sampling and output length.
+func (o *OllamaAdapter) request(messages []domain.Message, opts *domain.GenerateOptions, stream bool) (*ollamaChatRequest, error) {
+	if len(messages) == 0 {
+		return nil, types.New(types.ErrCodeInvalidInput, "no messages provided")
+	}
+
+	req := &ollamaChatRequest{
+		Model:     o.model,
+		Stream:    stream,
+		KeepAlive: o.opts.KeepAlive,
+		Options:   make(map[string]any, len(o.opts.Options)+4),
+	}
+	for k, v := range o.opts.Options {
+		req.Options[k] = v
+	}
+	if o.opts.NumCtx > 0 {
+		req.Options["num_ctx"] = o.opts.NumCtx
+	}
+
+	for i, m := range messages {
+		msg := ollamaMessage{Role: string(m.Role), Content: m.Content}
+		if m.Role == domain.RoleTool {
+			msg.ToolName = m.Name
+			if msg.ToolName == "" {
+				msg.ToolName = toolCallName(messages[:i], m.ToolCallID)
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			call := ollamaToolCall{}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = json.RawMessage(toolArguments(tc.Arguments))
+			if !json.Valid(call.Function.Arguments) {
+				return nil, types.Newf(types.ErrCodeInvalidInput, "tool call %s has invalid json arguments", tc.ID)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+
+	if opts != nil {
+		if opts.Model != "" {
+			req.Model = opts.Model
+		}
+		if opts.MaxTokens > 0 {
+			req.Options["num_predict"] = opts.MaxTokens
+		}
+		if t, ok := opts.TemperatureValue(); ok {
+			req.Options["temperature"] = t
+		}
+		if p, ok := opts.TopPValue(); ok {
+			req.Options["top_p"] = p
+		}
+		for _, t := range opts.Tools {
+			tool := ollamaTool{Type: "function"}
+			tool.Function.Name = t.Name
+			tool.Function.Description = t.Description
+			tool.Function.Parameters = t.Parameters
+			if tool.Function.Parameters == nil {
+				tool.Function.Parameters = &domain.Schema{Type: "object", Properties: map[string]*domain.Schema{}}
+			}
+			req.Tools = append(req.Tools, tool)
+		}
+		if opts.ResponseFormat != nil {
+			// A Schema holds only strings, slices and maps, so it always marshals.
+			req.Format, _ = json.Marshal(opts.ResponseFormat.Schema)
+		}
+	}
+	if len(req.Options) == 0 {
+		req.Options = nil
+	}
+	return req, nil
+}
+
+// fromOllamaToolCalls maps tool calls that follow offset earlier calls of the
+// same response. Ollama assigns no call IDs, so the ID is derived from name
+// and index.
+func fromOllamaToolCalls(calls []ollamaToolCall, offset int) []domain.ToolCall {
+	var out []domain.ToolCall
+	for i, tc := range calls {
+		out = append(out, domain.ToolCall{
+			ID:        toolCallID(tc.Function.Name, offset+i),
+			Name:      tc.Function.Name,
+			Arguments: toolArguments(string(tc.Function.Arguments)),
+		})
+	}
+	return out
+}
+
+// fromOllamaDoneReason maps done_reason, which Ollama reports as "stop" even
+// when the model called tools.
+func fromOllamaDoneReason(reason string, toolCalls bool) domain.FinishReason {
+	switch {
+	case toolCalls:
+		return domain.FinishToolCalls
+	case reason == "stop":
+		return domain.FinishStop
+	case reason == "length":
+		return domain.FinishLength
+	default:
+		return domain.FinishReason(reason)
+	}
+}
+
+// streamOllama forwards the newline-delimited responses of body to ch. Tool
+// calls arrive whole, so each is sent as a single delta.
+func streamOllama(ctx context.Context, body io.ReadCloser, ch chan<- domain.ChatChunk) {
+	defer close(ch)
+	defer body.Close()
+
+	var calls []domain.ToolCall
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		var resp ollamaChatResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			sendChunk(ctx, ch, domain.ChatChunk{Error: types.Wrap(err, types.ErrCodeAgentFailed, "invalid ollama stream response")})
+			return
+		}
+		if resp.Error != "" {
+			sendChunk(ctx, ch, domain.ChatChunk{Error: types.Newf(types.ErrCodeAgentFailed, "ollama streaming error: %s", resp.Error)})
+			return
+		}
+
+		chunk := domain.ChatChunk{Content: resp.Message.Content}
+		for _, tc := range fromOllamaToolCalls(resp.Message.ToolCalls, len(calls)) {
+			chunk.ToolCallDeltas = append(chunk.ToolCallDeltas, domain.ToolCallDelta{Index: len(calls), ID: tc.ID, Name: tc.Name, Arguments: tc.Arguments})
+			calls = append(calls, tc)
+		}
+		if resp.Done {
+			chunk.Done = true
+			chunk.ToolCalls = calls
+			usage := resp.usage()
+			chunk.Usage = &usage
+			chunk.FinishReason = fromOllamaDoneReason(resp.DoneReason, len(calls) > 0)
+			sendChunk(ctx, ch, chunk)
+			return
+		}
+		if chunk.Content != "" || len(chunk.ToolCallDeltas) > 0 {
+			if !sendChunk(ctx, ch, chunk) {
+				return
+			}
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	sendChunk(ctx, ch, domain.ChatChunk{Error: types.Wrap(err, types.ErrCodeAgentFailed, "ollama stream ended before done")})
+}
+
+// Ollama API wire types.
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Tools     []ollamaTool    `json:"tools,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Parameters  *domain.Schema `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (r ollamaChatResponse) usage() domain.Usage {
+	return domain.Usage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount}
+}
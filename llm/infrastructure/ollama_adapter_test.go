@@ -0,0 +1,266 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// fakeOllama serves the Ollama API for a set of installed models.
+type fakeOllama struct {
+	mu       sync.Mutex
+	models   map[string]bool
+	requests []map[string]any
+	pulls    int
+	// pullGate, when set, holds pulls until it is closed.
+	pullGate chan struct{}
+}
+
+func (f *fakeOllama) serve(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/pull" && f.pullGate != nil {
+			<-f.pullGate
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var req map[string]any
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+		}
+		model, _ := req["model"].(string)
+
+		switch r.URL.Path {
+		case "/api/tags":
+			var names []string
+			for name := range f.models {
+				names = append(names, fmt.Sprintf(`{"name":%q}`, name))
+			}
+			fmt.Fprintf(w, `{"models":[%s]}`, strings.Join(names, ","))
+		case "/api/show":
+			if !f.models[model] {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"error":"model '%s' not found"}`, model)
+				return
+			}
+			fmt.Fprint(w, `{"details":{"family":"llama"}}`)
+		case "/api/pull":
+			f.pulls++
+			fmt.Fprint(w, "{\"status\":\"pulling manifest\"}\n")
+			fmt.Fprint(w, "{\"status\":\"downloading\",\"digest\":\"sha256:1\",\"total\":100,\"completed\":50}\n")
+			fmt.Fprint(w, "{\"status\":\"downloading\",\"digest\":\"sha256:1\",\"total\":100,\"completed\":100}\n")
+			fmt.Fprint(w, "{\"status\":\"success\"}\n")
+			f.models[model] = true
+		case "/api/chat":
+			f.requests = append(f.requests, req)
+			if !f.models[model] {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"error":"model \"%s\" not found, try pulling it first"}`, model)
+				return
+			}
+			if req["stream"] == true {
+				fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"Hel\"},\"done\":false}\n")
+				fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"lo\"},\"done\":false}\n")
+				fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\",\"tool_calls\":[{\"function\":{\"name\":\"lookup_cve\",\"arguments\":{\"id\":\"CVE-1\"}}}]},\"done\":false}\n")
+				fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true,\"done_reason\":\"stop\",\"prompt_eval_count\":12,\"eval_count\":7}\n")
+				return
+			}
+			content := "hello"
+			if req["format"] != nil {
+				content = `{"severity":"low"}`
+			}
+			reply, _ := json.Marshal(content)
+			fmt.Fprintf(w, `{"model":%q,"message":{"role":"assistant","content":%s},"done":true,"done_reason":"length","prompt_eval_count":3,"eval_count":1}`, model, reply)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllama_RequestOptionsAndFormat(t *testing.T) {
+	fake := &fakeOllama{models: map[string]bool{"llama3.1": true}}
+	srv := fake.serve(t)
+
+	llm := NewOllamaAdapter("", srv.URL, OllamaOptions{
+		KeepAlive: "10m",
+		NumCtx:    8192,
+		Options:   map[string]any{"seed": 42, "temperature": 0.9},
+	})
+	res, err := llm.GenerateEx(context.Background(), []domain.Message{
+		{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "lookup_cve_0", Name: "lookup_cve", Arguments: `{"id":"CVE-1"}`}}},
+		{Role: domain.RoleTool, ToolCallID: "lookup_cve_0", Content: "not found"},
+	}, (&domain.GenerateOptions{MaxTokens: 64, Tools: []domain.Tool{lookupTool}}).SetTemperature(0))
+	if err != nil || res.Content != "hello" {
+		t.Fatalf("unexpected result %+v %v", res, err)
+	}
+	if res.Usage != (domain.Usage{PromptTokens: 3, CompletionTokens: 1}) || res.FinishReason != domain.FinishLength ||
+		res.Provider != "ollama" || res.Model != "llama3.1" {
+		t.Errorf("unexpected result metadata %+v", res)
+	}
+
+	raw, _ := json.Marshal(fake.requests[0])
+	for _, want := range []string{
+		`"keep_alive":"10m"`,
+		`"options":{"num_ctx":8192,"num_predict":64,"seed":42,"temperature":0}`,
+		`"stream":false`,
+		`"tool_calls":[{"function":{"arguments":{"id":"CVE-1"},"name":"lookup_cve"}}]`,
+		`{"content":"not found","role":"tool","tool_name":"lookup_cve"}`,
+		`"tools":[{"function":{"description":"Look up a CVE by ID","name":"lookup_cve","parameters":`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("request missing %s:\n%s", want, raw)
+		}
+	}
+
+	var result triage
+	if err := llm.GenerateJSON(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "triage"}}, nil, &result); err != nil || result.Severity != "low" {
+		t.Fatalf("unexpected json result %+v %v", result, err)
+	}
+	format, _ := json.Marshal(fake.requests[1]["format"])
+	if !strings.Contains(string(format), `"enum":["low","high"]`) {
+		t.Errorf("expected the schema as format, got %s", format)
+	}
+}
+
+func TestOllama_EmptyContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"model":"llama3.1","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":40,"eval_count":2}`)
+	}))
+	defer srv.Close()
+
+	res, err := NewOllamaAdapter("llama3.1", srv.URL, OllamaOptions{}).GenerateEx(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "done?"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Content != "" || res.FinishReason != domain.FinishStop || res.Usage != (domain.Usage{PromptTokens: 40, CompletionTokens: 2}) {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestOllama_AutoPullAndHealthCheck(t *testing.T) {
+	fake := &fakeOllama{models: map[string]bool{}}
+	srv := fake.serve(t)
+
+	var progress []OllamaPullProgress
+	llm := NewOllamaAdapter("qwen2.5", srv.URL, OllamaOptions{
+		AutoPull: true,
+		OnPull:   func(p OllamaPullProgress) { progress = append(progress, p) },
+	})
+
+	if err := llm.HealthCheck(context.Background()); err == nil {
+		t.Error("health check must fail while the model is missing")
+	}
+
+	if _, err := llm.Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fake.pulls != 1 || len(fake.requests) != 2 {
+		t.Errorf("expected one pull and a retried chat, got %d pulls and %d chats", fake.pulls, len(fake.requests))
+	}
+	if len(progress) != 4 || progress[2].Completed != 100 || progress[3].Status != "success" || progress[0].Model != "qwen2.5" {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	if err := llm.HealthCheck(context.Background()); err != nil {
+		t.Errorf("health check after pull: %v", err)
+	}
+	if models, err := llm.ListModels(context.Background()); err != nil || len(models) != 1 || models[0] != "qwen2.5" {
+		t.Errorf("unexpected models %v %v", models, err)
+	}
+
+	noPull := NewOllamaAdapter("missing", srv.URL, OllamaOptions{})
+	if _, err := noPull.Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil); err == nil {
+		t.Error("expected an error without AutoPull")
+	}
+}
+
+func TestOllama_AutoPullOutlivesCaller(t *testing.T) {
+	fake := &fakeOllama{models: map[string]bool{}, pullGate: make(chan struct{})}
+	srv := fake.serve(t)
+	llm := NewOllamaAdapter("qwen2.5", srv.URL, OllamaOptions{AutoPull: true})
+	inFlight := func() int {
+		llm.pullMu.Lock()
+		defer llm.pullMu.Unlock()
+		return len(llm.pulls)
+	}
+	waitFor := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for inFlight() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d pulls in flight, got %d", n, inFlight())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := llm.Generate(ctx, []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+		errc <- err
+	}()
+	waitFor(1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the caller to give up, got %v", err)
+	}
+
+	// The pull completes without its first caller.
+	close(fake.pullGate)
+	waitFor(0)
+	if _, err := llm.Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.pulls != 1 {
+		t.Errorf("expected one pull, got %d", fake.pulls)
+	}
+}
+
+func TestOllama_Stream(t *testing.T) {
+	fake := &fakeOllama{models: map[string]bool{"llama3.1": true}}
+	srv := fake.serve(t)
+
+	ch, err := NewOllamaAdapter("", srv.URL, OllamaOptions{}).Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content string
+	var final domain.ChatChunk
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatal(chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.Done {
+			final = chunk
+		}
+	}
+	if content != "Hello" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if len(final.ToolCalls) != 1 || final.ToolCalls[0].Arguments != `{"id":"CVE-1"}` || final.ToolCalls[0].ID != "lookup_cve_0" {
+		t.Errorf("unexpected tool calls %+v", final.ToolCalls)
+	}
+	if final.Usage == nil || *final.Usage != (domain.Usage{PromptTokens: 12, CompletionTokens: 7}) {
+		t.Errorf("unexpected usage %+v", final.Usage)
+	}
+	if final.FinishReason != domain.FinishToolCalls {
+		t.Errorf("unexpected finish reason %q", final.FinishReason)
+	}
+}
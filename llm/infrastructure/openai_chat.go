@@ -0,0 +1,152 @@
+package infrastructure
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIChat implements domain.LLM for every provider speaking the OpenAI
+// chat completions API. The adapters embed it and differ only in client
+// setup and defaults.
+type openAIChat struct {
+	name   string
+	client *openai.Client
+	model  string
+	// maxTokens and temperature apply when GenerateOptions leave them
+	// unset; a zero temperature keeps the provider default.
+	maxTokens   int
+	temperature float32
+	// jsonMode is how GenerateJSON requests structured output.
+	jsonMode structuredOutput
+	// streamUsage asks for the usage chunk at the end of streams
+	// (stream_options), which not every compatible server accepts.
+	streamUsage bool
+	// listModels makes HealthCheck list the models instead of fetching the
+	// configured one, for servers without GET /models/{id}.
+	listModels bool
+}
+
+// Name returns the provider identifier string
+func (c *openAIChat) Name() string {
+	return c.name
+}
+
+// Generate implements the standard LLM generate interface
+func (c *openAIChat) Generate(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (string, error) {
+	msg, err := c.GenerateMessage(ctx, messages, opts)
+	return msg.Content, err
+}
+
+// GenerateMessage implements domain.ToolCaller.
+func (c *openAIChat) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	res, err := c.GenerateEx(ctx, messages, opts)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return res.Message(), nil
+}
+
+// GenerateEx implements domain.ResultGenerator.
+func (c *openAIChat) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	start := time.Now()
+	resp, err := c.client.CreateChatCompletion(ctx, c.request(messages, opts, false))
+	if err != nil {
+		return domain.GenerateResult{}, types.Wrapf(err, types.ErrCodeAgentFailed, "%s generation failed", c.name)
+	}
+
+	if len(resp.Choices) == 0 {
+		return domain.GenerateResult{}, types.Newf(types.ErrCodeAgentFailed, "empty response received from %s", c.name)
+	}
+
+	msg := fromOpenAIMessage(resp.Choices[0].Message)
+	requestID := resp.Header().Get("X-Request-Id")
+	if requestID == "" {
+		requestID = resp.ID
+	}
+	return domain.GenerateResult{
+		Content:      msg.Content,
+		ToolCalls:    msg.ToolCalls,
+		Usage:        fromOpenAIUsage(resp.Usage),
+		FinishReason: fromOpenAIFinishReason(resp.Choices[0].FinishReason),
+		Provider:     c.name,
+		Model:        resp.Model,
+		RequestID:    requestID,
+		Latency:      time.Since(start),
+	}, nil
+}
+
+// Stream implements the LLM Port with streaming support
+func (c *openAIChat) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	stream, err := c.client.CreateChatCompletionStream(ctx, c.request(messages, opts, true))
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeAgentFailed, "%s streaming error", c.name)
+	}
+
+	ch := make(chan domain.ChatChunk)
+	go streamOpenAI(ctx, stream, ch)
+	return ch, nil
+}
+
+// HealthCheck verifies connectivity to the provider and, where the server
+// supports it, that the model exists.
+func (c *openAIChat) HealthCheck(ctx context.Context) error {
+	if c.listModels {
+		_, err := c.client.ListModels(ctx)
+		return err
+	}
+	_, err := c.client.GetModel(ctx, c.model)
+	return err
+}
+
+// GenerateJSON implements structured output enforcement.
+func (c *openAIChat) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	return generateJSON(ctx, c, c.jsonMode, messages, opts, target)
+}
+
+// request builds the completion request, applying every option over the
+// adapter defaults.
+func (c *openAIChat) request(messages []domain.Message, opts *domain.GenerateOptions, stream bool) openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model:       c.model,
+		Messages:    toOpenAIMessages(messages),
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		Stream:      stream,
+	}
+	if stream && c.streamUsage {
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
+	if opts != nil {
+		if opts.Model != "" {
+			req.Model = opts.Model
+		}
+		if opts.MaxTokens > 0 {
+			req.MaxTokens = opts.MaxTokens
+		}
+		if t, ok := opts.TemperatureValue(); ok {
+			req.Temperature = explicitFloat(t)
+		}
+		if p, ok := opts.TopPValue(); ok {
+			req.TopP = explicitFloat(p)
+		}
+	}
+
+	applyOpenAITools(&req, opts)
+	applyOpenAIResponseFormat(&req, opts)
+	return req
+}
+
+// explicitFloat keeps an explicit zero from being dropped by the omitempty
+// tags of go-openai, which documents math.SmallestNonzeroFloat32 for it.
+func explicitFloat(v float32) float32 {
+	if v == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return v
+}
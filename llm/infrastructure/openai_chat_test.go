@@ -0,0 +1,232 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/sashabaranov/go-openai"
+)
+
+// testClient points a go-openai client at srv.
+func testClient(srv *httptest.Server) *openai.Client {
+	config := openai.DefaultConfig("key")
+	config.BaseURL = srv.URL
+	return openai.NewClientWithConfig(config)
+}
+
+func TestOpenAIChat_RequestOptions(t *testing.T) {
+	srv, requests := chatServer(t, "ok")
+
+	adapters := map[string]*openAIChat{
+		"openai":     NewOpenAIAdapter("key", "").openAIChat,
+		"openrouter": NewOpenRouterAdapter("key", "").openAIChat,
+		"lmstudio":   NewLMStudioAdapter("", "", "").openAIChat,
+		"compatible": NewOpenAICompatibleAdapter("compatible", "key", "model", "").(*OpenAICompatibleAdapter).openAIChat,
+	}
+	for _, c := range adapters {
+		c.client = testClient(srv)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		adapter string
+		opts    *domain.GenerateOptions
+		want    map[string]any
+		absent  []string
+	}{
+		{
+			name:    "openai defaults",
+			adapter: "openai",
+			want:    map[string]any{"model": openai.GPT4o, "max_tokens": 5000.0},
+			absent:  []string{"temperature", "top_p"},
+		},
+		{
+			name:    "openrouter defaults",
+			adapter: "openrouter",
+			want:    map[string]any{"model": "arcee-ai/trinity-large-preview:free", "max_tokens": 5000.0},
+			absent:  []string{"temperature"},
+		},
+		{
+			name:    "lmstudio defaults",
+			adapter: "lmstudio",
+			want:    map[string]any{"model": "local-model", "max_tokens": 4000.0},
+		},
+		{
+			name:    "compatible defaults",
+			adapter: "compatible",
+			want:    map[string]any{"model": "model", "max_tokens": 4096.0, "temperature": 0.7},
+		},
+		{
+			name:    "overrides",
+			adapter: "openai",
+			opts:    &domain.GenerateOptions{Model: "gpt-4o-mini", MaxTokens: 100, Temperature: 0.2, TopP: 0.9},
+			want: map[string]any{
+				"model": "gpt-4o-mini", "max_tokens": 100.0,
+				"temperature": 0.2, "top_p": 0.9,
+			},
+		},
+		{
+			name:    "unset zero keeps the adapter temperature",
+			adapter: "compatible",
+			opts:    &domain.GenerateOptions{MaxTokens: 10},
+			want:    map[string]any{"max_tokens": 10.0, "temperature": 0.7},
+		},
+		{
+			name:    "explicit zero temperature and top_p",
+			adapter: "compatible",
+			opts:    (&domain.GenerateOptions{}).SetTemperature(0).SetTopP(0),
+			want:    map[string]any{"temperature": 0.0, "top_p": 0.0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			*requests = nil
+			if _, err := adapters[tc.adapter].Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			req := (*requests)[0]
+			for key, want := range tc.want {
+				got, ok := req[key]
+				// An explicit zero goes out as the smallest float32, which
+				// providers treat as zero.
+				if f, isFloat := got.(float64); isFloat && want == 0.0 && f > 0 && f < 1e-40 {
+					got = 0.0
+				}
+				if !ok || got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			for _, key := range tc.absent {
+				if v, ok := req[key]; ok {
+					t.Errorf("%s should be omitted, got %v", key, v)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIChat_StreamAppliesOptions(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	llm := NewOpenRouterAdapter("key", "")
+	llm.client = testClient(srv)
+	ch, err := llm.Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}},
+		(&domain.GenerateOptions{Model: "other", MaxTokens: 42}).SetTemperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatal(chunk.Error)
+		}
+		content += chunk.Content
+	}
+
+	if content != "ok" || got["stream"] != true || got["model"] != "other" || got["max_tokens"] != 42.0 {
+		t.Errorf("unexpected stream request %v (content %q)", got, content)
+	}
+	if _, ok := got["temperature"]; !ok {
+		t.Error("explicit zero temperature was dropped from the stream request")
+	}
+}
+
+func TestOpenAIChat_GenerateEx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req_1")
+		fmt.Fprint(w, `{"id":"gen-1","model":"openai/gpt-4o-2024-08-06","choices":[{"index":0,"finish_reason":"length",
+			"message":{"role":"assistant","content":"truncated"}}],
+			"usage":{"prompt_tokens":120,"completion_tokens":8,"prompt_tokens_details":{"cached_tokens":100}}}`)
+	}))
+	defer srv.Close()
+
+	llm := NewOpenRouterAdapter("key", "")
+	llm.client = testClient(srv)
+	res, err := llm.GenerateEx(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Content != "truncated" || res.FinishReason != domain.FinishLength {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if res.Usage != (domain.Usage{PromptTokens: 120, CompletionTokens: 8, CacheReadTokens: 100}) {
+		t.Errorf("unexpected usage %+v", res.Usage)
+	}
+	if res.Provider != "openrouter" || res.Model != "openai/gpt-4o-2024-08-06" || res.RequestID != "req_1" || res.Latency <= 0 {
+		t.Errorf("unexpected result metadata %+v", res)
+	}
+}
+
+func TestOpenAIChat_StreamReportsUsage(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":9,\"completion_tokens\":1}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	llm := NewOpenAIAdapter("key", "")
+	llm.client = testClient(srv)
+	ch, err := llm.Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var final domain.ChatChunk
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatal(chunk.Error)
+		}
+		if chunk.Done {
+			final = chunk
+		}
+	}
+
+	if opts, _ := got["stream_options"].(map[string]any); opts["include_usage"] != true {
+		t.Errorf("usage not requested: %v", got)
+	}
+	if final.Usage == nil || *final.Usage != (domain.Usage{PromptTokens: 9, CompletionTokens: 1}) {
+		t.Errorf("unexpected usage %+v", final.Usage)
+	}
+	if final.FinishReason != domain.FinishStop {
+		t.Errorf("unexpected finish reason %q", final.FinishReason)
+	}
+}
+
+func TestOpenAIChat_HealthCheckProbe(t *testing.T) {
+	// Like LM Studio, the server lists its models but cannot fetch one.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"local-model","object":"model"}]}`)
+	}))
+	defer srv.Close()
+
+	if err := NewLMStudioAdapter("", "", srv.URL+"/v1").HealthCheck(context.Background()); err != nil {
+		t.Errorf("lmstudio health check should list models: %v", err)
+	}
+	if err := NewOpenAICompatibleAdapter("test", "key", "local-model", srv.URL+"/v1").HealthCheck(context.Background()); err == nil {
+		t.Error("expected the model lookup to fail")
+	}
+}
@@ -0,0 +1,187 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	_ domain.ToolCaller = (*OpenAIAdapter)(nil)
+	_ domain.ToolCaller = (*OpenRouterAdapter)(nil)
+	_ domain.ToolCaller = (*LMStudioAdapter)(nil)
+	_ domain.ToolCaller = (*OpenAICompatibleAdapter)(nil)
+
+	_ domain.ResultGenerator = (*OpenAIAdapter)(nil)
+	_ domain.ResultGenerator = (*OpenRouterAdapter)(nil)
+	_ domain.ResultGenerator = (*LMStudioAdapter)(nil)
+	_ domain.ResultGenerator = (*OpenAICompatibleAdapter)(nil)
+)
+
+// toOpenAIMessages maps domain messages, including tool calls and tool
+// results, to the OpenAI chat format.
+func toOpenAIMessages(messages []domain.Message) []openai.ChatCompletionMessage {
+	reqMessages := make([]openai.ChatCompletionMessage, len(messages))
+	for i, m := range messages {
+		msg := openai.ChatCompletionMessage{
+			Role:       string(m.Role),
+			Content:    m.Content,
+			Name:       m.Name,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, tc := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       tc.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+			})
+		}
+		reqMessages[i] = msg
+	}
+	return reqMessages
+}
+
+// applyOpenAITools sets the tools and tool choice of opts on req.
+func applyOpenAITools(req *openai.ChatCompletionRequest, opts *domain.GenerateOptions) {
+	if opts == nil || len(opts.Tools) == 0 {
+		return
+	}
+	for _, t := range opts.Tools {
+		fn := &openai.FunctionDefinition{Name: t.Name, Description: t.Description}
+		if t.Parameters != nil {
+			fn.Parameters = t.Parameters
+		} else {
+			fn.Parameters = &domain.Schema{Type: "object", Properties: map[string]*domain.Schema{}}
+		}
+		req.Tools = append(req.Tools, openai.Tool{Type: openai.ToolTypeFunction, Function: fn})
+	}
+
+	switch {
+	case opts.ToolChoice.Name != "":
+		req.ToolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: opts.ToolChoice.Name}}
+	case opts.ToolChoice.Mode != "":
+		req.ToolChoice = string(opts.ToolChoice.Mode)
+	}
+}
+
+// fromOpenAIMessage maps an assistant message of a completion to the domain.
+func fromOpenAIMessage(msg openai.ChatCompletionMessage) domain.Message {
+	out := domain.Message{
+		Role:    domain.RoleAssistant,
+		Content: msg.Content,
+	}
+	for _, tc := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, domain.ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return out
+}
+
+// streamOpenAI forwards content and tool-call deltas of stream to ch. A
+// final Done chunk carries the assembled tool calls, the finish reason and
+// the usage when the server sent it.
+func streamOpenAI(ctx context.Context, stream *openai.ChatCompletionStream, ch chan<- domain.ChatChunk) {
+	defer close(ch)
+	defer stream.Close()
+
+	calls := make(map[int]*domain.ToolCall)
+	final := domain.ChatChunk{Done: true}
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if len(calls) > 0 {
+					final.ToolCalls = assembleToolCalls(calls)
+				}
+				sendChunk(ctx, ch, final)
+				return
+			}
+			sendChunk(ctx, ch, domain.ChatChunk{Error: err})
+			return
+		}
+		if response.Usage != nil {
+			usage := fromOpenAIUsage(*response.Usage)
+			final.Usage = &usage
+		}
+		if len(response.Choices) == 0 {
+			continue
+		}
+		if reason := response.Choices[0].FinishReason; reason != "" {
+			final.FinishReason = fromOpenAIFinishReason(reason)
+		}
+
+		delta := response.Choices[0].Delta
+		chunk := domain.ChatChunk{Content: delta.Content}
+		for i, tc := range delta.ToolCalls {
+			index := i
+			if tc.Index != nil {
+				index = *tc.Index
+			}
+			call, ok := calls[index]
+			if !ok {
+				call = &domain.ToolCall{}
+				calls[index] = call
+			}
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Function.Name != "" {
+				call.Name = tc.Function.Name
+			}
+			call.Arguments += tc.Function.Arguments
+			chunk.ToolCallDeltas = append(chunk.ToolCallDeltas, domain.ToolCallDelta{
+				Index:     index,
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+		if chunk.Content != "" || len(chunk.ToolCallDeltas) > 0 {
+			if !sendChunk(ctx, ch, chunk) {
+				return
+			}
+		}
+	}
+}
+
+func assembleToolCalls(calls map[int]*domain.ToolCall) []domain.ToolCall {
+	indexes := make([]int, 0, len(calls))
+	for i := range calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	out := make([]domain.ToolCall, len(indexes))
+	for i, index := range indexes {
+		out[i] = *calls[index]
+	}
+	return out
+}
+
+// fromOpenAIUsage maps the token usage of a completion. Cached prompt tokens
+// stay included in PromptTokens, as reported.
+func fromOpenAIUsage(u openai.Usage) domain.Usage {
+	usage := domain.Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+	if u.PromptTokensDetails != nil {
+		usage.CacheReadTokens = u.PromptTokensDetails.CachedTokens
+	}
+	return usage
+}
+
+func fromOpenAIFinishReason(reason openai.FinishReason) domain.FinishReason {
+	switch reason {
+	case openai.FinishReasonFunctionCall:
+		return domain.FinishToolCalls
+	case openai.FinishReasonNull:
+		return ""
+	default:
+		return domain.FinishReason(reason)
+	}
+}
@@ -0,0 +1,153 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/google/generative-ai-go/genai"
+)
+
+var lookupTool = domain.Tool{
+	Name:        "lookup_cve",
+	Description: "Look up a CVE by ID",
+	Parameters: &domain.Schema{
+		Type:       "object",
+		Properties: map[string]*domain.Schema{"id": {Type: "string"}},
+		Required:   []string{"id"},
+	},
+}
+
+func TestOpenAITools_RequestAndResponseMapping(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"",
+			"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup_cve","arguments":"{\"id\":\"CVE-2024-1\"}"}}]}}]}`)
+	}))
+	defer srv.Close()
+
+	llm := NewOpenAICompatibleAdapter("test", "key", "model", srv.URL).(domain.ToolCaller)
+	msg, err := llm.GenerateMessage(context.Background(), []domain.Message{
+		{Role: domain.RoleUser, Content: "check CVE-2024-0"},
+		{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "call_0", Name: "lookup_cve", Arguments: `{"id":"CVE-2024-0"}`}}},
+		{Role: domain.RoleTool, ToolCallID: "call_0", Content: `{"severity":"high"}`},
+	}, &domain.GenerateOptions{
+		Tools:      []domain.Tool{lookupTool},
+		ToolChoice: domain.ToolChoice{Name: "lookup_cve"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0] != (domain.ToolCall{ID: "call_1", Name: "lookup_cve", Arguments: `{"id":"CVE-2024-1"}`}) {
+		t.Errorf("unexpected tool calls: %+v", msg.ToolCalls)
+	}
+
+	raw, _ := json.Marshal(got)
+	for _, want := range []string{
+		`"tools":[{"function":{"description":"Look up a CVE by ID","name":"lookup_cve","parameters":{"properties":{"id":{"type":"string"}},"required":["id"],"type":"object"}},"type":"function"}]`,
+		`"tool_choice":{"function":{"name":"lookup_cve"},"type":"function"}`,
+		`"tool_calls":[{"function":{"arguments":"{\"id\":\"CVE-2024-0\"}","name":"lookup_cve"},"id":"call_0","type":"function"}]`,
+		`"tool_call_id":"call_0"`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("request missing %s:\n%s", want, raw)
+		}
+	}
+}
+
+func TestOpenAITools_StreamAssemblesArgumentDeltas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{
+			`{"content":"Checking"}`,
+			`{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup_cve","arguments":""}}]}`,
+			`{"tool_calls":[{"index":0,"function":{"arguments":"{\"id\":"}}]}`,
+			`{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"lookup_cve","arguments":"{}"}}]}`,
+			`{"tool_calls":[{"index":0,"function":{"arguments":"\"CVE-1\"}"}}]}`,
+		} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":%s}]}\n\n", delta)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	llm := NewOpenAICompatibleAdapter("test", "key", "model", srv.URL)
+	ch, err := llm.Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}},
+		&domain.GenerateOptions{Tools: []domain.Tool{lookupTool}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content string
+	var deltas int
+	var final domain.ChatChunk
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatal(chunk.Error)
+		}
+		content += chunk.Content
+		deltas += len(chunk.ToolCallDeltas)
+		if chunk.Done {
+			final = chunk
+		}
+	}
+
+	if content != "Checking" || deltas != 4 {
+		t.Errorf("unexpected stream: content %q, %d deltas", content, deltas)
+	}
+	want := []domain.ToolCall{
+		{ID: "call_1", Name: "lookup_cve", Arguments: `{"id":"CVE-1"}`},
+		{ID: "call_2", Name: "lookup_cve", Arguments: `{}`},
+	}
+	if len(final.ToolCalls) != 2 || final.ToolCalls[0] != want[0] || final.ToolCalls[1] != want[1] {
+		t.Errorf("unexpected final tool calls: %+v", final.ToolCalls)
+	}
+}
+
+func TestGeminiTools_Mapping(t *testing.T) {
+	model := &genai.GenerativeModel{}
+	applyGenaiTools(model, &domain.GenerateOptions{
+		Tools:      []domain.Tool{lookupTool},
+		ToolChoice: domain.ToolChoice{Mode: domain.ToolChoiceRequired},
+	})
+	params := model.Tools[0].FunctionDeclarations[0].Parameters
+	if params.Type != genai.TypeObject || params.Properties["id"].Type != genai.TypeString || params.Required[0] != "id" {
+		t.Errorf("unexpected schema: %+v", params)
+	}
+	if model.ToolConfig.FunctionCallingConfig.Mode != genai.FunctionCallingAny {
+		t.Errorf("unexpected tool config: %+v", model.ToolConfig.FunctionCallingConfig)
+	}
+
+	contents := toGenaiContents([]domain.Message{
+		{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "lookup_cve_0", Name: "lookup_cve", Arguments: `{"id":"CVE-1"}`}}},
+		{Role: domain.RoleTool, ToolCallID: "lookup_cve_0", Content: "not found"},
+	})
+	call, ok := contents[0].Parts[0].(genai.FunctionCall)
+	if contents[0].Role != "model" || !ok || call.Args["id"] != "CVE-1" {
+		t.Errorf("unexpected assistant content: %+v", contents[0])
+	}
+	resp, ok := contents[1].Parts[0].(genai.FunctionResponse)
+	if !ok || resp.Name != "lookup_cve" || resp.Response["content"] != "not found" {
+		t.Errorf("unexpected tool content: %+v", contents[1])
+	}
+
+	text, calls := fromGenaiParts([]genai.Part{
+		genai.Text("ok"),
+		genai.FunctionCall{Name: "lookup_cve", Args: map[string]any{"id": "CVE-2"}},
+		genai.FunctionCall{Name: "lookup_cve", Args: map[string]any{"id": "CVE-3"}},
+	})
+	if text != "ok" || len(calls) != 2 || calls[0].Arguments != `{"id":"CVE-2"}` ||
+		calls[0].ID != "lookup_cve_0" || calls[1].ID != "lookup_cve_1" {
+		t.Errorf("unexpected response mapping: %q %+v", text, calls)
+	}
+}
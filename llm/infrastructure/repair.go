@@ -0,0 +1,172 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// extractJSON returns the first balanced JSON value in resp opening with one
+// of the roots bytes ('{' or '['), with comments and trailing commas removed,
+// skipping prose and markdown fences around it. Candidates that still fail
+// to parse are skipped. It returns false when no candidate parses.
+func extractJSON(resp string, roots string) (string, bool) {
+	for start := 0; start < len(resp); start++ {
+		if strings.IndexByte(roots, resp[start]) < 0 {
+			continue
+		}
+		end, ok := balancedEnd(resp, start)
+		if !ok {
+			continue
+		}
+		candidate := stripJSONNoise(resp[start:end])
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// balancedEnd finds the end of the object or array opening at start,
+// honoring strings and comments.
+func balancedEnd(s string, start int) (int, bool) {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			i = stringEnd(s, i)
+		case c == '/' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '*'):
+			i = commentEnd(s, i)
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// stripJSONNoise removes // and /* */ comments and trailing commas before a
+// closing bracket, leaving string contents untouched.
+func stripJSONNoise(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			end := stringEnd(s, i)
+			b.WriteString(s[i : end+1])
+			i = end
+		case c == '/' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '*'):
+			i = commentEnd(s, i)
+		case c == ',':
+			j := i + 1
+			for j < len(s) {
+				if strings.IndexByte(" \t\r\n", s[j]) >= 0 {
+					j++
+				} else if s[j] == '/' && j+1 < len(s) && (s[j+1] == '/' || s[j+1] == '*') {
+					j = commentEnd(s, j) + 1
+				} else {
+					break
+				}
+			}
+			if j < len(s) && (s[j] == '}' || s[j] == ']') {
+				continue
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index of the closing quote of the string at i.
+func stringEnd(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+// commentEnd returns the index of the last byte of the comment at i.
+func commentEnd(s string, i int) int {
+	if s[i+1] == '/' {
+		if nl := strings.IndexByte(s[i:], '\n'); nl >= 0 {
+			return i + nl - 1
+		}
+		return len(s) - 1
+	}
+	if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+		return i + 2 + end + 1
+	}
+	return len(s) - 1
+}
+
+// JSONAttempt records one rejected response of the GenerateJSON repair
+// loop. The ErrCodeAgentFailed AppError returned after the last attempt lists
+// them under the "attempts" context key.
+type JSONAttempt struct {
+	Response string   `json:"response"`
+	Errors   []string `json:"errors"`
+}
+
+// decodeJSON extracts, validates and decodes resp into target, which is
+// only written on success. It returns the problems found otherwise.
+func decodeJSON(resp string, schema *domain.Schema, target interface{}) []string {
+	roots := "{["
+	switch schema.Type {
+	case "object":
+		roots = "{"
+	case "array":
+		roots = "["
+	}
+	raw, ok := strings.TrimSpace(resp), true
+	if !json.Valid([]byte(raw)) {
+		raw, ok = extractJSON(resp, roots)
+	}
+	if !ok {
+		return []string{"the response contains no valid JSON object or array"}
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal([]byte(raw), &generic); err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, verr := range schema.Validate(generic) {
+		problems = append(problems, verr.Error())
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	// Decode into a copy so a failed attempt leaves target untouched, while
+	// fields the response omits keep their pre-filled defaults.
+	rv := reflect.ValueOf(target)
+	fresh := reflect.New(rv.Elem().Type())
+	fresh.Elem().Set(rv.Elem())
+	if err := json.Unmarshal([]byte(raw), fresh.Interface()); err != nil {
+		return []string{err.Error()}
+	}
+	rv.Elem().Set(fresh.Elem())
+	return nil
+}
+
+// repairPrompt asks the model to correct its previous response.
+func repairPrompt(problems []string) domain.Message {
+	return domain.Message{
+		Role: domain.RoleUser,
+		Content: "Your previous response was not valid:\n- " + strings.Join(problems, "\n- ") +
+			"\nReply with the corrected JSON only.",
+	}
+}
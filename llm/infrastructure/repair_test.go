@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+)
+
+func TestExtractJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, roots, want string
+	}{
+		{"prose", `Sure! Here is the result: {"a": 1} Hope this helps.`, "{[", `{"a": 1}`},
+		{"fence", "```json\n{\"a\": [1, 2]}\n```", "{[", `{"a": [1, 2]}`},
+		{"trailing commas", `{"a": [1, 2,], "b": {"c": 3,},}`, "{[", `{"a": [1, 2], "b": {"c": 3}}`},
+		{"comments", "{\n  // severity\n  \"a\": 1, /* note */ \"b\": \"//not a comment\"\n}", "{[",
+			"{\n  \n  \"a\": 1,  \"b\": \"//not a comment\"\n}"},
+		{"comment before close", "{\"a\": 1, // last\n}", "{[", "{\"a\": 1 \n}"},
+		{"braces in strings", `{"a": "}{", "b": "\"]"}`, "{[", `{"a": "}{", "b": "\"]"}`},
+		{"skips invalid candidate", `{not json} then {"ok": true}`, "{[", `{"ok": true}`},
+		{"root kind", `Found [2] issues: {"n": 2}`, "{", `{"n": 2}`},
+		{"array root", `Issues: [{"n": 1}]`, "[", `[{"n": 1}]`},
+	} {
+		got, ok := extractJSON(tc.in, tc.roots)
+		if !ok || got != tc.want {
+			t.Errorf("%s: got %q (%v), want %q", tc.name, got, ok, tc.want)
+		}
+	}
+	if got, ok := extractJSON("no json here", "{["); ok {
+		t.Errorf("expected no match, got %q", got)
+	}
+}
+
+type scoredFinding struct {
+	Severity string `json:"severity" enum:"low,high"`
+	Score    int    `json:"score" minimum:"0" maximum:"10"`
+	Note     string `json:"note,omitempty"`
+}
+
+// scriptedLLM answers Generate calls with scripted responses and records
+// the messages of each call.
+type scriptedLLM struct {
+	domain.LLM
+	responses []string
+	calls     [][]domain.Message
+}
+
+func (s *scriptedLLM) Generate(_ context.Context, messages []domain.Message, _ *domain.GenerateOptions) (string, error) {
+	s.calls = append(s.calls, messages)
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func TestGenerateJSON_RepairsWithValidationFeedback(t *testing.T) {
+	llm := &scriptedLLM{responses: []string{
+		`I think it's {"severity": "critical", "score": 11}`,
+		"```json\n{\"severity\": \"high\", \"score\": 9,}\n```",
+	}}
+
+	// Pre-filled fields the response omits keep their value.
+	out := scoredFinding{Note: "from rule R-42"}
+	err := generateJSON(context.Background(), llm, schemaNative, []domain.Message{{Role: domain.RoleUser, Content: "triage"}}, nil, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != (scoredFinding{Severity: "high", Score: 9, Note: "from rule R-42"}) {
+		t.Errorf("unexpected result %+v", out)
+	}
+
+	if len(llm.calls) != 2 || len(llm.calls[1]) != 3 {
+		t.Fatalf("expected a repair call with the previous answer, got %v", llm.calls)
+	}
+	feedback := llm.calls[1][2].Content
+	for _, want := range []string{`$.score: 11 is above the maximum 10`, `$.severity: "critical" is not one of low, high`} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("repair prompt missing %q:\n%s", want, feedback)
+		}
+	}
+}
+
+func TestGenerateJSON_GivesUpWithEveryAttempt(t *testing.T) {
+	llm := &scriptedLLM{responses: []string{`nope`, `{"score": "high"}`}}
+
+	out := scoredFinding{Note: "untouched"}
+	err := generateJSON(context.Background(), llm, schemaNative, nil, &domain.GenerateOptions{JSONRepairAttempts: 1}, &out)
+
+	var appErr *types.AppError
+	if !errors.As(err, &appErr) || appErr.Code != types.ErrCodeAgentFailed {
+		t.Fatalf("expected an invalid input AppError, got %v", err)
+	}
+	attempts, _ := appErr.Context["attempts"].([]JSONAttempt)
+	if len(attempts) != 2 || attempts[0].Response != "nope" ||
+		strings.Join(attempts[1].Errors, "; ") != `$: missing required property "severity"; $.score: expected an integer, got a string` {
+		t.Errorf("unexpected attempts: %+v", attempts)
+	}
+	if out.Note != "untouched" {
+		t.Errorf("target must not be written on failure: %+v", out)
+	}
+}
@@ -0,0 +1,489 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	_ domain.ToolCaller      = (*RetryingLLM)(nil)
+	_ domain.ResultGenerator = (*RetryingLLM)(nil)
+)
+
+// RetryPolicy bounds the retries of a RetryingLLM.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, first call included
+	// (default 4).
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the exponential backoff used when the
+	// provider gives no hint (defaults 500ms and 30s). Each delay is drawn
+	// with full jitter. A provider hint longer than MaxDelay ends the retries
+	// with the provider's error: retrying sooner than asked would only hit
+	// the same limit again.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 4
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	return p
+}
+
+// RetryingLLM decorates a domain.LLM, retrying calls that failed with a
+// transient provider error (rate limits, overload, 5xx, dropped
+// connections). Retry-After and x-ratelimit-* headers take precedence over
+// the backoff, and no retry is attempted when the wait would pass MaxDelay
+// or the context deadline. A stream is only retried before its first chunk.
+type RetryingLLM struct {
+	llm    domain.LLM
+	policy RetryPolicy
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	// sleep waits for d or until ctx is done; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryingLLM wraps llm with retries following policy.
+func NewRetryingLLM(llm domain.LLM, policy RetryPolicy) *RetryingLLM {
+	return &RetryingLLM{
+		llm:    llm,
+		policy: policy.withDefaults(),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		sleep:  sleepContext,
+	}
+}
+
+// Name returns the name of the wrapped provider.
+func (r *RetryingLLM) Name() string {
+	return r.llm.Name()
+}
+
+// Unwrap returns the decorated LLM.
+func (r *RetryingLLM) Unwrap() domain.LLM {
+	return r.llm
+}
+
+// Generate implements the standard LLM generate interface
+func (r *RetryingLLM) Generate(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (string, error) {
+	var out string
+	err := r.retry(ctx, func(ctx context.Context) error {
+		var err error
+		out, err = r.llm.Generate(ctx, messages, opts)
+		return err
+	})
+	return out, err
+}
+
+// GenerateMessage implements domain.ToolCaller. Providers without tool
+// support answer through Generate.
+func (r *RetryingLLM) GenerateMessage(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.Message, error) {
+	var out domain.Message
+	err := r.retry(ctx, func(ctx context.Context) error {
+		var err error
+		if tc, ok := r.llm.(domain.ToolCaller); ok {
+			out, err = tc.GenerateMessage(ctx, messages, opts)
+			return err
+		}
+		out = domain.Message{Role: domain.RoleAssistant}
+		out.Content, err = r.llm.Generate(ctx, messages, opts)
+		return err
+	})
+	return out, err
+}
+
+// GenerateEx implements domain.ResultGenerator. Latency covers the last
+// attempt only. Providers without result support answer through
+// GenerateMessage or Generate, with only Provider and Latency set.
+func (r *RetryingLLM) GenerateEx(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (domain.GenerateResult, error) {
+	var out domain.GenerateResult
+	err := r.retry(ctx, func(ctx context.Context) error {
+		var err error
+		if rg, ok := r.llm.(domain.ResultGenerator); ok {
+			out, err = rg.GenerateEx(ctx, messages, opts)
+			return err
+		}
+		start := time.Now()
+		out = domain.GenerateResult{Provider: r.llm.Name()}
+		if tc, ok := r.llm.(domain.ToolCaller); ok {
+			var msg domain.Message
+			msg, err = tc.GenerateMessage(ctx, messages, opts)
+			out.Content, out.ToolCalls = msg.Content, msg.ToolCalls
+		} else {
+			out.Content, err = r.llm.Generate(ctx, messages, opts)
+		}
+		out.Latency = time.Since(start)
+		return err
+	})
+	return out, err
+}
+
+// GenerateJSON implements structured output enforcement, retrying the whole
+// exchange on transient errors.
+func (r *RetryingLLM) GenerateJSON(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions, target interface{}) error {
+	return r.retry(ctx, func(ctx context.Context) error {
+		return r.llm.GenerateJSON(ctx, messages, opts, target)
+	})
+}
+
+// HealthCheck is not retried, so that it reports the current state.
+func (r *RetryingLLM) HealthCheck(ctx context.Context) error {
+	return r.llm.HealthCheck(ctx)
+}
+
+// Close closes the wrapped provider when it holds resources.
+func (r *RetryingLLM) Close() error {
+	if closer, ok := r.llm.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Stream implements the LLM Port with streaming support. Opening the stream
+// and an error as its very first chunk are retried; once a chunk has been
+// delivered the output is never replayed, and later errors reach the caller.
+func (r *RetryingLLM) Stream(ctx context.Context, messages []domain.Message, opts *domain.GenerateOptions) (<-chan domain.ChatChunk, error) {
+	var out chan domain.ChatChunk
+	err := r.retry(ctx, func(attemptCtx context.Context) error {
+		streamCtx, cancel := context.WithCancel(attemptCtx)
+		src, err := r.llm.Stream(streamCtx, messages, opts)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		var first domain.ChatChunk
+		var ok bool
+		select {
+		case first, ok = <-src:
+		case <-ctx.Done():
+			cancel()
+			go drainChunks(src)
+			return ctx.Err()
+		}
+		if ok && first.Error != nil && !first.Done {
+			cancel()
+			go drainChunks(src)
+			return first.Error
+		}
+
+		out = make(chan domain.ChatChunk)
+		go func() {
+			defer close(out)
+			defer cancel()
+			for chunk := first; ok; chunk, ok = <-src {
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					go drainChunks(src)
+					return
+				}
+			}
+		}()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// retry runs call until it succeeds, fails permanently or runs out of
+// attempts or time. Each attempt records the response headers of failed
+// HTTP calls for the retry hints.
+func (r *RetryingLLM) retry(ctx context.Context, call func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		headers := &capturedHeaders{}
+		err := call(context.WithValue(ctx, capturedHeadersKey{}, headers))
+		if err == nil || ctx.Err() != nil || attempt >= r.policy.MaxAttempts {
+			return err
+		}
+
+		retryable, hint := classifyLLMError(err, headers.get())
+		if !retryable {
+			return err
+		}
+
+		if hint > r.policy.MaxDelay {
+			return err
+		}
+		wait := hint
+		if wait <= 0 {
+			wait = r.backoff(attempt)
+		} else {
+			wait += r.jitter(wait / 10)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+		if r.sleep(ctx, wait) != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the full-jitter exponential delay before attempt+1.
+func (r *RetryingLLM) backoff(attempt int) time.Duration {
+	d := r.policy.BaseDelay << (attempt - 1)
+	if d <= 0 || d > r.policy.MaxDelay {
+		d = r.policy.MaxDelay
+	}
+	return r.jitter(d)
+}
+
+// jitter returns a random duration in [0, d].
+func (r *RetryingLLM) jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Duration(r.rand.Int63n(int64(d) + 1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drainChunks discards the rest of an abandoned stream so its producer can
+// exit.
+func drainChunks(src <-chan domain.ChatChunk) {
+	for range src {
+	}
+}
+
+// classifyLLMError reports whether err is transient and how long the
+// provider asked to wait, from header (captured from the failed response)
+// or from the error itself.
+func classifyLLMError(err error, header http.Header) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+
+	status := 0
+	var (
+		openaiErr    *openai.APIError
+		requestErr   *openai.RequestError
+		anthropicErr *anthropicAPIError
+		ollamaErr    *ollamaAPIError
+		googleErr    *googleapi.Error
+	)
+	switch {
+	case errors.As(err, &openaiErr):
+		status = openaiErr.HTTPStatusCode
+	case errors.As(err, &requestErr):
+		status = requestErr.HTTPStatusCode
+	case errors.As(err, &anthropicErr):
+		status = anthropicErr.StatusCode
+		if anthropicErr.Header != nil {
+			header = anthropicErr.Header
+		}
+		if anthropicErr.StatusCode == 0 && anthropicErr.Type == "overloaded_error" {
+			// Overload reported as an event inside a stream.
+			return true, 0
+		}
+	case errors.As(err, &ollamaErr):
+		status = ollamaErr.StatusCode
+	case errors.As(err, &googleErr):
+		status = googleErr.Code
+		if googleErr.Header != nil {
+			header = googleErr.Header
+		}
+	}
+	if status != 0 {
+		return retryableStatus(status), retryHint(header, status)
+	}
+
+	if st, ok := grpcStatus(err); ok {
+		switch st.Code() {
+		case codes.ResourceExhausted, codes.Unavailable, codes.Aborted, codes.Internal, codes.DeadlineExceeded:
+			return true, grpcRetryDelay(st)
+		default:
+			return false, 0
+		}
+	}
+
+	// Transport failures: timeouts and dropped connections.
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true, 0
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true, 0
+	}
+	return false, 0
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout, 529: // 529: Anthropic overloaded
+		return true
+	}
+	return false
+}
+
+// retryHint reads how long to wait from Retry-After (seconds or HTTP date),
+// retry-after-ms, and, on 429, the reset time of an exhausted
+// x-ratelimit-* budget.
+func retryHint(header http.Header, status int) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	if status != http.StatusTooManyRequests {
+		return 0
+	}
+
+	var wait time.Duration
+	for _, budget := range []string{"requests", "tokens"} {
+		if header.Get("X-Ratelimit-Remaining-"+budget) != "0" {
+			continue
+		}
+		if d := parseReset(header.Get("X-Ratelimit-Reset-" + budget)); d > wait {
+			wait = d
+		}
+	}
+	// OpenRouter style: a single reset in epoch milliseconds.
+	if wait == 0 && header.Get("X-Ratelimit-Remaining") == "0" {
+		wait = parseReset(header.Get("X-Ratelimit-Reset"))
+	}
+	return wait
+}
+
+// parseReset reads a rate-limit reset as a Go duration ("1s", "6m0s"), a
+// number of seconds, an epoch time in seconds or milliseconds, or an RFC 3339
+// time.
+func parseReset(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		switch {
+		case n > 1e12:
+			return time.Until(time.UnixMilli(int64(n)))
+		case n > 1e9:
+			return time.Until(time.Unix(int64(n), 0))
+		default:
+			return time.Duration(n * float64(time.Second))
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func grpcStatus(err error) (*status.Status, bool) {
+	var withStatus interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &withStatus) {
+		return nil, false
+	}
+	st := withStatus.GRPCStatus()
+	return st, st != nil
+}
+
+// grpcRetryDelay returns the RetryInfo delay of a gRPC status, which Gemini
+// sets on RESOURCE_EXHAUSTED.
+func grpcRetryDelay(st *status.Status) time.Duration {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+// capturedHeaders holds the response headers of the last failed HTTP call
+// of an attempt. RetryingLLM puts one in the context; captureTransport fills
+// it, since SDK errors such as go-openai's drop the headers.
+type capturedHeaders struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+type capturedHeadersKey struct{}
+
+func (c *capturedHeaders) set(h http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header = h
+}
+
+func (c *capturedHeaders) get() http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.header
+}
+
+// captureTransport records the headers of failed responses into the
+// capturedHeaders of the request context.
+type captureTransport struct {
+	base http.RoundTripper
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil && resp.StatusCode >= 400 {
+		if headers, ok := req.Context().Value(capturedHeadersKey{}).(*capturedHeaders); ok {
+			headers.set(resp.Header.Clone())
+		}
+	}
+	return resp, err
+}
+
+// newCaptureTransport wraps base (http.DefaultTransport when nil) to expose
+// failed response headers to RetryingLLM.
+func newCaptureTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &captureTransport{base: base}
+}
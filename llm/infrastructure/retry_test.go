@@ -0,0 +1,230 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// scriptedReply is one scripted HTTP response; an empty body means a
+// successful completion.
+type scriptedReply struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// scriptedServer answers chat completions with replies in order, repeating
+// the last one.
+func scriptedServer(t *testing.T, replies ...scriptedReply) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reply := replies[min(calls, len(replies)-1)]
+		calls++
+		mu.Unlock()
+
+		for k, v := range reply.header {
+			w.Header().Set(k, v)
+		}
+		if reply.status == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`)
+			return
+		}
+		w.WriteHeader(reply.status)
+		fmt.Fprint(w, reply.body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+// recordWaits replaces the sleep of r, returning the waits requested.
+func recordWaits(r *RetryingLLM) *[]time.Duration {
+	var waits []time.Duration
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return &waits
+}
+
+const rateLimited = `{"error":{"message":"Rate limit reached","type":"requests"}}`
+
+func TestRetry_HonorsProviderHints(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		reply   scriptedReply
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{
+			name:    "retry-after seconds",
+			reply:   scriptedReply{status: 429, header: map[string]string{"Retry-After": "2"}, body: rateLimited},
+			minWait: 2 * time.Second, maxWait: 2200 * time.Millisecond,
+		},
+		{
+			name:    "retry-after-ms",
+			reply:   scriptedReply{status: 429, header: map[string]string{"Retry-After-Ms": "250", "Retry-After": "1"}, body: rateLimited},
+			minWait: 250 * time.Millisecond, maxWait: 275 * time.Millisecond,
+		},
+		{
+			name: "exhausted token budget",
+			reply: scriptedReply{status: 429, body: rateLimited, header: map[string]string{
+				"X-Ratelimit-Remaining-Requests": "10", "X-Ratelimit-Reset-Requests": "20s",
+				"X-Ratelimit-Remaining-Tokens": "0", "X-Ratelimit-Reset-Tokens": "1.5s",
+			}},
+			minWait: 1500 * time.Millisecond, maxWait: 1650 * time.Millisecond,
+		},
+		{
+			name:    "server error backoff",
+			reply:   scriptedReply{status: 503, body: `{"error":{"message":"overloaded"}}`},
+			minWait: 0, maxWait: 100 * time.Millisecond,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, calls := scriptedServer(t, tc.reply, scriptedReply{})
+			llm := NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{BaseDelay: 100 * time.Millisecond})
+			waits := recordWaits(llm)
+
+			out, err := llm.Generate(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+			if err != nil || out != "ok" {
+				t.Fatalf("unexpected result %q %v", out, err)
+			}
+			if calls() != 2 || len(*waits) != 1 {
+				t.Fatalf("expected one retry, got %d calls and waits %v", calls(), *waits)
+			}
+			if w := (*waits)[0]; w < tc.minWait || w > tc.maxWait {
+				t.Errorf("wait %s outside [%s, %s]", w, tc.minWait, tc.maxWait)
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	msgs := []domain.Message{{Role: domain.RoleUser, Content: "hi"}}
+
+	srv, calls := scriptedServer(t, scriptedReply{status: 400, body: `{"error":{"message":"bad request"}}`})
+	llm := NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{})
+	recordWaits(llm)
+	if _, err := llm.Generate(context.Background(), msgs, nil); err == nil || calls() != 1 {
+		t.Errorf("client errors must not be retried: %d calls, %v", calls(), err)
+	}
+
+	srv, calls = scriptedServer(t, scriptedReply{status: 502, body: "bad gateway"})
+	llm = NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{MaxAttempts: 3})
+	waits := recordWaits(llm)
+	if _, err := llm.Generate(context.Background(), msgs, nil); err == nil || calls() != 3 || len(*waits) != 2 {
+		t.Errorf("expected 3 attempts, got %d calls, waits %v, %v", calls(), *waits, err)
+	}
+
+	// A wait past the deadline is not worth starting.
+	srv, calls = scriptedServer(t, scriptedReply{status: 429, header: map[string]string{"Retry-After": "20"}, body: rateLimited})
+	llm = NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{})
+	waits = recordWaits(llm)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := llm.Generate(ctx, msgs, nil); err == nil || calls() != 1 || len(*waits) != 0 {
+		t.Errorf("expected no retry past the deadline, got %d calls, waits %v", calls(), *waits)
+	}
+
+	// Neither is a hint longer than MaxDelay.
+	srv, calls = scriptedServer(t, scriptedReply{status: 429, header: map[string]string{"Retry-After": "3600"}, body: rateLimited}, scriptedReply{})
+	llm = NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{MaxDelay: time.Minute})
+	waits = recordWaits(llm)
+	if _, err := llm.Generate(context.Background(), msgs, nil); err == nil || calls() != 1 || len(*waits) != 0 {
+		t.Errorf("expected no retry beyond MaxDelay, got %d calls, waits %v", calls(), *waits)
+	}
+}
+
+func TestRetry_StreamOnlyBeforeFirstChunk(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"message":"overloaded"}}`)
+			return
+		}
+		// A partial stream cut off before [DONE].
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {broken\n\n")
+	}))
+	defer srv.Close()
+
+	llm := NewRetryingLLM(NewLMStudioAdapter("", "model", srv.URL), RetryPolicy{})
+	recordWaits(llm)
+	ch, err := llm.Stream(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	var streamErr error
+	for chunk := range ch {
+		content += chunk.Content
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+	}
+
+	if content != "Hel" || streamErr == nil {
+		t.Errorf("expected the partial output then its error, got %q %v", content, streamErr)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Errorf("expected one retry to open the stream and none after output, got %d calls", calls)
+	}
+}
+
+func TestClassifyLLMError(t *testing.T) {
+	gemini, _ := status.New(codes.ResourceExhausted, "quota exceeded").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(37 * time.Second)})
+
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retryable bool
+		wait      time.Duration
+	}{
+		{"googleapi 429", &googleapi.Error{Code: 429, Header: http.Header{"Retry-After": {"3"}}}, true, 3 * time.Second},
+		{"googleapi 403", &googleapi.Error{Code: 403}, false, 0},
+		{"grpc retry info", fmt.Errorf("gemini: %w", gemini.Err()), true, 37 * time.Second},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "bad"), false, 0},
+		{"anthropic overloaded", &anthropicAPIError{StatusCode: 529, Type: "overloaded_error"}, true, 0},
+		{"ollama missing model", &ollamaAPIError{StatusCode: 404, Message: "model not found"}, false, 0},
+		{"blocked", &domain.BlockedError{Provider: "gemini", Reason: "SAFETY"}, false, 0},
+		{"cancelled", context.Canceled, false, 0},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true, 0},
+		{"unknown", errors.New("boom"), false, 0},
+	} {
+		retryable, wait := classifyLLMError(tc.err, nil)
+		if retryable != tc.retryable || wait != tc.wait {
+			t.Errorf("%s: got (%v, %s), want (%v, %s)", tc.name, retryable, wait, tc.retryable, tc.wait)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package infrastructure
+
+import (
+	"encoding/json"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// applyOpenAIResponseFormat requests a json_schema response when opts carry
+// a ResponseFormat. The schema is not strict, so optional fields stay optional.
+func applyOpenAIResponseFormat(req *openai.ChatCompletionRequest, opts *domain.GenerateOptions) {
+	if opts == nil || opts.ResponseFormat == nil {
+		return
+	}
+	// A Schema holds only strings, slices and maps, so it always marshals.
+	raw, _ := json.Marshal(opts.ResponseFormat.Schema)
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   opts.ResponseFormat.Name,
+			Schema: json.RawMessage(raw),
+		},
+	}
+}
+
+// applyGenaiResponseFormat constrains a Gemini model to JSON matching the
+// ResponseFormat of opts.
+func applyGenaiResponseFormat(model *genai.GenerativeModel, opts *domain.GenerateOptions) {
+	if opts == nil || opts.ResponseFormat == nil {
+		return
+	}
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = toGenaiSchema(opts.ResponseFormat.Schema)
+}
@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/google/generative-ai-go/genai"
+)
+
+type triage struct {
+	Severity string `json:"severity" enum:"low,high"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+// chatServer answers every completion with content and records the requests.
+func chatServer(t *testing.T, content string) (*httptest.Server, *[]map[string]any) {
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		reply, _ := json.Marshal(content)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%s}}]}`, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestGenerateJSON_NativeSchema(t *testing.T) {
+	srv, requests := chatServer(t, `{"severity":"high"}`)
+	llm := NewLMStudioAdapter("", "local", srv.URL)
+
+	var out triage
+	if err := llm.GenerateJSON(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "triage"}}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Severity != "high" {
+		t.Errorf("unexpected result %+v", out)
+	}
+
+	raw, _ := json.Marshal((*requests)[0]["response_format"])
+	want := `{"json_schema":{"name":"triage","schema":{"additionalProperties":false,` +
+		`"properties":{"severity":{"enum":["low","high"],"type":"string"},"summary":{"type":"string"}},` +
+		`"required":["severity"],"type":"object"},"strict":false},"type":"json_schema"}`
+	if string(raw) != want {
+		t.Errorf("unexpected response_format:\n got %s\nwant %s", raw, want)
+	}
+	if msgs := (*requests)[0]["messages"].([]any); len(msgs) != 1 {
+		t.Errorf("native mode must not add a schema prompt: %v", msgs)
+	}
+}
+
+func TestGenerateJSON_PromptSchema(t *testing.T) {
+	srv, requests := chatServer(t, "```json\n[{\"severity\":\"low\"}]\n```")
+	llm := NewOpenAICompatibleAdapter("custom", "key", "model", srv.URL)
+
+	var out []triage
+	if err := llm.GenerateJSON(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "triage"}}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0].Severity != "low" {
+		t.Errorf("unexpected result %+v", out)
+	}
+
+	req := (*requests)[0]
+	if _, ok := req["response_format"]; ok {
+		t.Error("prompt mode must not send response_format")
+	}
+	first := req["messages"].([]any)[0].(map[string]any)
+	if first["role"] != "system" || !strings.Contains(first["content"].(string), `"enum":["low","high"]`) {
+		t.Errorf("expected the schema in a system prompt, got %v", first)
+	}
+}
+
+func TestGenaiResponseFormat(t *testing.T) {
+	schema, err := domain.SchemaOf(&triage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := &genai.GenerativeModel{}
+	applyGenaiResponseFormat(model, &domain.GenerateOptions{ResponseFormat: &domain.ResponseFormat{Name: "triage", Schema: schema}})
+
+	if model.ResponseMIMEType != "application/json" || model.ResponseSchema == nil {
+		t.Fatalf("response format not applied: %+v", model.GenerationConfig)
+	}
+	sev := model.ResponseSchema.Properties["severity"]
+	if sev.Type != genai.TypeString || sev.Format != "enum" || len(sev.Enum) != 2 || model.ResponseSchema.Required[0] != "severity" {
+		t.Errorf("unexpected gemini schema: %+v", model.ResponseSchema)
+	}
+}
+
+func TestGenaiSchema_MapsAndAnyFallBackToPrompt(t *testing.T) {
+	type evidence struct {
+		Labels  map[string]string `json:"labels"`
+		Detail  interface{}       `json:"detail"`
+		Payload []byte            `json:"payload"`
+		Seen    time.Time         `json:"seen"`
+	}
+	schema, err := domain.SchemaOf(&evidence{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genaiExpressible(schema) {
+		t.Error("maps and interface fields should not be expressible")
+	}
+	if plain, _ := domain.SchemaOf(&triage{}); !genaiExpressible(plain) {
+		t.Error("plain structs should be expressible")
+	}
+
+	out := toGenaiSchema(schema)
+	if out.Properties["detail"].Type != genai.TypeUnspecified {
+		t.Errorf("any value mapped to %v", out.Properties["detail"].Type)
+	}
+	if out.Properties["payload"].Format != "" || out.Properties["seen"].Format != "date-time" {
+		t.Errorf("unexpected formats: payload %q, seen %q", out.Properties["payload"].Format, out.Properties["seen"].Format)
+	}
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OverflowPolicy decides what an asynchronous stream does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the logging goroutine wait for free space.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being written.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest buffered entry to make room.
+	OverflowDropOldest
+)
+
+// AsyncConfig moves the file and sink I/O of a stream off the logging goroutine.
+type AsyncConfig struct {
+	// BufferSize is the ring buffer capacity in entries. Default 4096.
+	BufferSize int
+	// Overflow must be OverflowBlock for the audit and security streams.
+	Overflow OverflowPolicy
+	// FlushInterval is how often the underlying writer and sinks are synced. Default 1s.
+	FlushInterval time.Duration
+}
+
+// AsyncStats reports the health of an asynchronous stream.
+type AsyncStats struct {
+	Enqueued uint64
+	Written  uint64
+	Dropped  uint64
+	Pending  int
+
+	// Latency is measured from enqueue until the entry was written.
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+}
+
+type asyncItem struct {
+	data []byte
+	at   time.Time
+}
+
+// asyncWriter buffers encoded entries in a bounded ring and writes them from
+// a single goroutine, preserving order.
+type asyncWriter struct {
+	out    zapcore.WriteSyncer
+	policy OverflowPolicy
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	ring     []asyncItem
+	head     int
+	size     int
+	inflight bool
+	closed   bool
+	// drained is set once run has written the last buffered entry.
+	drained bool
+
+	enqueued     uint64
+	written      uint64
+	dropped      uint64
+	totalLatency time.Duration
+	maxLatency   time.Duration
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func newAsyncWriter(out zapcore.WriteSyncer, cfg AsyncConfig) *asyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 4096
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	w := &asyncWriter{
+		out:    out,
+		policy: cfg.Overflow,
+		ring:   make([]asyncItem, cfg.BufferSize),
+		done:   make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	w.wg.Add(2)
+	go w.run()
+	go w.flushEvery(cfg.FlushInterval)
+	return w
+}
+
+// Write copies p into the ring, applying the overflow policy when it is full.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	item := asyncItem{data: append([]byte(nil), p...), at: time.Now()}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.size == len(w.ring) && !w.closed {
+		switch w.policy {
+		case OverflowDropNewest:
+			w.dropped++
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = asyncItem{}
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+			w.dropped++
+		default:
+			w.cond.Wait()
+		}
+	}
+
+	if w.closed {
+		// Late writers after Close go straight to the destination, but only
+		// after the buffered entries, so the order is kept.
+		for !w.drained {
+			w.cond.Wait()
+		}
+		w.mu.Unlock()
+		_, err := w.out.Write(p)
+		w.mu.Lock()
+		return len(p), err
+	}
+
+	w.ring[(w.head+w.size)%len(w.ring)] = item
+	w.size++
+	w.enqueued++
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+func (w *asyncWriter) run() {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		for w.size == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.size == 0 {
+			w.drained = true
+			w.cond.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+		item := w.ring[w.head]
+		w.ring[w.head] = asyncItem{}
+		w.head = (w.head + 1) % len(w.ring)
+		w.size--
+		w.inflight = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		_, _ = w.out.Write(item.data)
+		latency := time.Since(item.at)
+
+		w.mu.Lock()
+		w.inflight = false
+		w.written++
+		w.totalLatency += latency
+		if latency > w.maxLatency {
+			w.maxLatency = latency
+		}
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+func (w *asyncWriter) flushEvery(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.out.Sync()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Sync blocks until every entry buffered before the call has been written,
+// then syncs the destination.
+func (w *asyncWriter) Sync() error {
+	w.mu.Lock()
+	for (w.size > 0 || w.inflight) && !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	return w.out.Sync()
+}
+
+// close drains the buffer and stops the background goroutines.
+func (w *asyncWriter) close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.out.Sync()
+}
+
+func (w *asyncWriter) stats() AsyncStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st := AsyncStats{
+		Enqueued:   w.enqueued,
+		Written:    w.written,
+		Dropped:    w.dropped,
+		Pending:    w.size,
+		MaxLatency: w.maxLatency,
+	}
+	if w.written > 0 {
+		st.AvgLatency = w.totalLatency / time.Duration(w.written)
+	}
+	return st
+}
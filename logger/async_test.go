@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks every write until the gate is opened.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	got  []string
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.got = append(g.got, string(p))
+	return len(p), nil
+}
+
+func (g *gatedWriter) Sync() error { return nil }
+
+func TestAsyncWriter_OverflowPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{OverflowDropNewest, []string{"0", "1", "2"}},
+		{OverflowDropOldest, []string{"0", "3", "4"}},
+	} {
+		out := &gatedWriter{gate: make(chan struct{})}
+		w := newAsyncWriter(out, AsyncConfig{BufferSize: 2, Overflow: tc.policy})
+
+		w.Write([]byte("0"))
+		// Wait until "0" is in flight so the ring is empty again.
+		w.mu.Lock()
+		for !w.inflight {
+			w.cond.Wait()
+		}
+		w.mu.Unlock()
+
+		for i := 1; i < 5; i++ {
+			w.Write([]byte(strconv.Itoa(i)))
+		}
+		close(out.gate)
+		w.Sync()
+
+		if len(out.got) != len(tc.want) {
+			t.Fatalf("policy %d: expected %v, got %v", tc.policy, tc.want, out.got)
+		}
+		for i := range tc.want {
+			if out.got[i] != tc.want[i] {
+				t.Errorf("policy %d: expected %v, got %v", tc.policy, tc.want, out.got)
+				break
+			}
+		}
+		if st := w.stats(); st.Dropped != 2 || st.Written != 3 || st.Pending != 0 {
+			t.Errorf("policy %d: unexpected stats %+v", tc.policy, st)
+		}
+		w.close()
+	}
+}
+
+func TestAsyncWriter_LateWritesFollowTheDrain(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	w := newAsyncWriter(out, AsyncConfig{BufferSize: 4})
+	for i := 0; i < 3; i++ {
+		w.Write([]byte(strconv.Itoa(i)))
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		w.close()
+		close(closed)
+	}()
+	w.mu.Lock()
+	for !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+
+	late := make(chan struct{})
+	go func() {
+		w.Write([]byte("late"))
+		close(late)
+	}()
+	// Give the late writer time to reach the destination if it did not wait.
+	time.Sleep(10 * time.Millisecond)
+	close(out.gate)
+	<-closed
+	<-late
+
+	want := []string{"0", "1", "2", "late"}
+	if len(out.got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, out.got)
+	}
+	for i := range want {
+		if out.got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, out.got)
+		}
+	}
+}
+
+func TestLogger_AsyncSyncDrainsAllStreams(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir),
+		WithAsync(StreamApp, AsyncConfig{BufferSize: 8, Overflow: OverflowBlock}),
+		WithAsync(StreamAudit, AsyncConfig{BufferSize: 8}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				l.Info(ctx, "tick", "tick")
+				l.LogAudit(ctx, "resource_read", "agent", "read", "scan")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(readEntries(t, filepath.Join(dir, "app.log"))); n != 200 {
+		t.Errorf("expected 200 app entries after Sync, got %d", n)
+	}
+	report, err := VerifyAuditChain(dir, nil)
+	if err != nil || report.Break != nil || report.Entries != 200 {
+		t.Errorf("audit chain should stay intact through the async buffer: %+v %v", report, err)
+	}
+	if st := l.AsyncStats()[StreamApp]; st.Written != 200 || st.Dropped != 0 {
+		t.Errorf("unexpected app stats %+v", st)
+	}
+}
+
+func TestWithAsync_RejectsDroppingLosslessStreams(t *testing.T) {
+	_, err := New("test", "info", WithLogDir(t.TempDir()), WithAsync(StreamSecurity, AsyncConfig{Overflow: OverflowDropOldest}))
+	if err == nil {
+		t.Fatal("expected drop policy on security stream to be rejected")
+	}
+}
+
+func TestNew_StopsAsyncWritersOnError(t *testing.T) {
+	dir := t.TempDir()
+	// An unreadable audit stream makes resuming the chain fail.
+	if err := os.Mkdir(filepath.Join(dir, "audit.log"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+	_, err := New("test", "info", WithLogDir(dir),
+		WithAsync(StreamApp, AsyncConfig{}),
+		WithAsync(StreamError, AsyncConfig{}),
+	)
+	if err == nil {
+		t.Fatal("expected New to fail")
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("async writer goroutines leaked: %d running, %d before", n, before)
+	}
+}
@@ -5,16 +5,127 @@ import (
 
 	"github.com/SecDuckOps/shared/ports"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// LogAuditEvent logs a structured audit event to the audit log (audit.log).
+func (l *Logger) LogAuditEvent(ctx context.Context, event ports.AuditEvent) {
+	l.checkEvent(event.Event, event.Fields, "actor", "action", "resource", "outcome")
+	event.Reason = l.redactor.RedactString(event.Reason)
+	if len(event.Changes) > 0 {
+		changes := make([]ports.AuditChange, len(event.Changes))
+		for i, c := range event.Changes {
+			changes[i] = ports.AuditChange{
+				Field:  c.Field,
+				Before: l.redactor.Redact(c.Field, c.Before),
+				After:  l.redactor.Redact(c.Field, c.After),
+			}
+		}
+		event.Changes = changes
+	}
+
+	zapFields := l.toZapFields(event.Fields)
+	zapFields = append(zapFields, auditEventFields(event)...)
+	l.routed(ctx, StreamAudit, l.auditZap, zapcore.InfoLevel, "Audit Event", l.withContextFields(ctx, zapFields))
+}
+
 // LogAudit logs an important system action to the audit log (audit.log).
+//
+// Deprecated: use LogAuditEvent. Entries keep their original shape, with the
+// actor and resource as plain strings, so existing queries and dashboards
+// still match.
 func (l *Logger) LogAudit(ctx context.Context, event string, actor string, action string, resource string, fields ...ports.Field) {
-	zapFields := toZapFields(fields)
+	l.checkEvent(event, fields, "actor", "action", "resource")
+
+	zapFields := l.toZapFields(fields)
 	zapFields = append(zapFields,
 		zap.String("event", event),
 		zap.String("actor", actor),
 		zap.String("action", action),
 		zap.String("resource", resource),
 	)
-	l.auditZap.Info("Audit Event", l.withContextFields(ctx, zapFields)...)
+	l.routed(ctx, StreamAudit, l.auditZap, zapcore.InfoLevel, "Audit Event", l.withContextFields(ctx, zapFields))
+}
+
+// auditEventFields serializes an AuditEvent with a stable set of keys so every
+// audit entry has the same shape regardless of which fields were populated.
+func auditEventFields(e ports.AuditEvent) []zap.Field {
+	outcome := string(e.Outcome)
+	if outcome == "" {
+		outcome = "unknown"
+	}
+
+	fields := []zap.Field{
+		zap.String("event", e.Event),
+		zap.Object("actor", auditActor(e.Actor)),
+		zap.String("action", string(e.Action)),
+		zap.Object("resource", auditResource(e.Resource)),
+		zap.String("outcome", outcome),
+	}
+	if e.Reason != "" {
+		fields = append(fields, zap.String("reason", e.Reason))
+	}
+	if len(e.Changes) > 0 {
+		fields = append(fields, zap.Array("changes", auditChanges(e.Changes)))
+	}
+	if e.Request != nil {
+		fields = append(fields, zap.Object("request", auditRequest(*e.Request)))
+	}
+	return fields
+}
+
+type auditActor ports.AuditActor
+
+func (a auditActor) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", a.ID)
+	enc.AddString("kind", string(a.Kind))
+	if a.IP != "" {
+		enc.AddString("ip", a.IP)
+	}
+	return nil
+}
+
+type auditResource ports.AuditResource
+
+func (r auditResource) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", r.Type)
+	enc.AddString("id", r.ID)
+	return nil
+}
+
+type auditChanges []ports.AuditChange
+
+func (c auditChanges) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, change := range c {
+		change := change
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			oe.AddString("field", change.Field)
+			if err := oe.AddReflected("before", change.Before); err != nil {
+				return err
+			}
+			return oe.AddReflected("after", change.After)
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type auditRequest ports.AuditRequest
+
+func (r auditRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if r.ID != "" {
+		enc.AddString("id", r.ID)
+	}
+	if r.Method != "" {
+		enc.AddString("method", r.Method)
+	}
+	if r.Path != "" {
+		enc.AddString("path", r.Path)
+	}
+	if r.UserAgent != "" {
+		enc.AddString("user_agent", r.UserAgent)
+	}
+	return nil
 }
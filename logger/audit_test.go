@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/ports"
+)
+
+func readEntries(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+func TestLogAuditEvent_SerializesStructuredEvent(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.LogAuditEvent(context.Background(), ports.AuditEvent{
+		Event:    "policy_updated",
+		Actor:    ports.AuditActor{ID: "u-1", Kind: ports.ActorUser, IP: "10.0.0.1"},
+		Action:   ports.ActionUpdate,
+		Resource: ports.AuditResource{Type: "policy", ID: "p-9"},
+		Outcome:  ports.OutcomeDenied,
+		Reason:   "missing role",
+		Changes:  []ports.AuditChange{{Field: "mode", Before: "audit", After: "enforce"}},
+		Request:  &ports.AuditRequest{ID: "req-1", Method: "PUT"},
+	})
+	l.LogAudit(context.Background(), "legacy_event", "bob", "delete", "scan-1")
+	_ = l.Sync()
+
+	entries := readEntries(t, filepath.Join(dir, "audit.log"))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	e := entries[0]
+	actor := e["actor"].(map[string]interface{})
+	if actor["kind"] != "user" || actor["ip"] != "10.0.0.1" {
+		t.Errorf("unexpected actor: %v", actor)
+	}
+	if e["outcome"] != "denied" || e["action"] != "update" {
+		t.Errorf("unexpected outcome/action: %v / %v", e["outcome"], e["action"])
+	}
+	changes := e["changes"].([]interface{})
+	if changes[0].(map[string]interface{})["after"] != "enforce" {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+
+	legacy := entries[1]
+	if legacy["actor"] != "bob" || legacy["action"] != "delete" || legacy["resource"] != "scan-1" {
+		t.Errorf("legacy shim produced unexpected entry: %v", legacy)
+	}
+	if _, ok := legacy["outcome"]; ok {
+		t.Errorf("legacy shim produced unexpected entry: %v", legacy)
+	}
+}
@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/SecDuckOps/shared/ports"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EventDef documents one event name that services may log.
+type EventDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Level is the default level used by Logger.Emit: debug, info, warn or error.
+	Level string `json:"level"`
+	// RequiredFields are field keys every entry of the event must carry.
+	RequiredFields []string `json:"required_fields,omitempty"`
+}
+
+// ValidationMode controls how a Logger reacts to events that violate its catalog.
+type ValidationMode int
+
+const (
+	// ValidateOff disables catalog checks.
+	ValidateOff ValidationMode = iota
+	// ValidateWarn logs an "event_catalog_violation" warning next to the entry.
+	ValidateWarn
+	// ValidateStrict logs the violation at error level and records it for
+	// Logger.CatalogError, so dev and tests can fail on drift without the
+	// logger crashing the host.
+	ValidateStrict
+)
+
+// maxCatalogViolations caps the distinct violations kept for CatalogError.
+const maxCatalogViolations = 100
+
+var eventNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// builtinEvents are emitted by the logger itself and are always accepted.
+var builtinEvents = []EventDef{
+	{Name: "log_suppressed", Description: "Summary of entries dropped by sampling or rate limiting.", Level: "info", RequiredFields: []string{"stream", "suppressed_event", "suppressed_count"}},
+	{Name: "event_catalog_violation", Description: "An entry used an unregistered event or missed required fields.", Level: "warn", RequiredFields: []string{"offending_event", "violation"}},
+}
+
+// Catalog is a registry of the event taxonomy shared between services.
+// It is safe for concurrent use.
+type Catalog struct {
+	mu     sync.RWMutex
+	events map[string]EventDef
+}
+
+// NewCatalog creates a catalog containing the logger's built-in events.
+func NewCatalog() *Catalog {
+	c := &Catalog{events: make(map[string]EventDef)}
+	c.MustRegister(builtinEvents...)
+	return c
+}
+
+// Register adds event definitions. Names must be snake_case and unique. The
+// batch is registered as a whole or, on error, not at all.
+func (c *Catalog) Register(defs ...EventDef) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	batch := make(map[string]EventDef, len(defs))
+	for _, def := range defs {
+		if !eventNamePattern.MatchString(def.Name) {
+			return fmt.Errorf("invalid event name %q: must be snake_case", def.Name)
+		}
+		if _, exists := c.events[def.Name]; exists {
+			return fmt.Errorf("event %q is already registered", def.Name)
+		}
+		if _, exists := batch[def.Name]; exists {
+			return fmt.Errorf("event %q is registered twice", def.Name)
+		}
+		if def.Level == "" {
+			def.Level = "info"
+		}
+		// dpanic, panic and fatal would make every Emit of the event
+		// panic or exit the process.
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(def.Level)); err != nil || lvl > zapcore.ErrorLevel {
+			return fmt.Errorf("event %q has invalid level %q", def.Name, def.Level)
+		}
+		batch[def.Name] = def
+	}
+	for name, def := range batch {
+		c.events[name] = def
+	}
+	return nil
+}
+
+// MustRegister is Register for package-level catalogs; it panics on error.
+func (c *Catalog) MustRegister(defs ...EventDef) {
+	if err := c.Register(defs...); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the definition of an event.
+func (c *Catalog) Lookup(name string) (EventDef, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	def, ok := c.events[name]
+	return def, ok
+}
+
+// Events returns every definition sorted by name.
+func (c *Catalog) Events() []EventDef {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	defs := make([]EventDef, 0, len(c.events))
+	for _, def := range c.events {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// ExportJSON renders the catalog for dashboard builders.
+func (c *Catalog) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Events []EventDef `json:"events"`
+	}{Events: c.Events()}, "", "  ")
+}
+
+// Validate checks an event name and the field keys of an entry against the catalog.
+func (c *Catalog) Validate(event string, keys []string) error {
+	def, ok := c.Lookup(event)
+	if !ok {
+		return fmt.Errorf("event %q is not registered", event)
+	}
+
+	present := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		present[k] = true
+	}
+	var missing []string
+	for _, req := range def.RequiredFields {
+		if !present[req] {
+			missing = append(missing, req)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("event %q is missing required fields %v", event, missing)
+	}
+	return nil
+}
+
+// WithCatalog validates every logged event against catalog.
+func WithCatalog(catalog *Catalog, mode ValidationMode) Option {
+	return func(o *options) {
+		o.catalog = catalog
+		o.validation = mode
+	}
+}
+
+// Emit logs a registered event at the default level of its definition.
+// Unregistered events are logged at info level after validation.
+func (l *Logger) Emit(ctx context.Context, event string, msg string, fields ...ports.Field) {
+	level := zapcore.InfoLevel
+	if l.catalog != nil {
+		if def, ok := l.catalog.Lookup(event); ok {
+			_ = level.UnmarshalText([]byte(def.Level))
+		}
+	}
+
+	l.checkEvent(event, fields)
+	zapFields := l.toZapFields(fields)
+	zapFields = append(zapFields, zap.String("event", event))
+	l.zap.Log(level, l.redactor.RedactString(msg), l.withContextFields(ctx, zapFields)...)
+}
+
+// checkEvent applies the catalog validation mode. implicit lists keys the
+// calling method always writes, e.g. "actor" for audit entries.
+func (l *Logger) checkEvent(event string, fields []ports.Field, implicit ...string) {
+	if l.catalog == nil || l.validation == ValidateOff {
+		return
+	}
+
+	keys := make([]string, 0, len(fields)+len(implicit))
+	for _, f := range fields {
+		keys = append(keys, f.Key)
+	}
+	keys = append(keys, implicit...)
+
+	err := l.catalog.Validate(event, keys)
+	if err == nil {
+		return
+	}
+	level := zapcore.WarnLevel
+	if l.validation == ValidateStrict {
+		level = zapcore.ErrorLevel
+		l.recordViolation(err)
+	}
+	l.zap.Log(level, "Event catalog violation",
+		zap.String("event", "event_catalog_violation"),
+		zap.String("offending_event", event),
+		zap.String("violation", err.Error()),
+	)
+}
+
+// recordViolation keeps err for CatalogError, once per distinct message.
+func (l *Logger) recordViolation(err error) {
+	l.violationsMu.Lock()
+	defer l.violationsMu.Unlock()
+	if len(l.violations) >= maxCatalogViolations {
+		return
+	}
+	for _, seen := range l.violations {
+		if seen.Error() == err.Error() {
+			return
+		}
+	}
+	l.violations = append(l.violations, err)
+}
+
+// CatalogError returns the distinct catalog violations seen in ValidateStrict
+// mode, joined in the order they first occurred, or nil.
+func (l *Logger) CatalogError() error {
+	l.violationsMu.Lock()
+	defer l.violationsMu.Unlock()
+	return errors.Join(l.violations...)
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/ports"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	c := NewCatalog()
+	err := c.Register(
+		EventDef{Name: "scan_completed", Description: "A scan finished.", Level: "info", RequiredFields: []string{"scan_id"}},
+		EventDef{Name: "operation_failed", Description: "An operation failed.", Level: "error"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCatalog_RegisterRejectsInvalidDefinitions(t *testing.T) {
+	c := testCatalog(t)
+	for _, def := range []EventDef{
+		{Name: "Scan Completed"},
+		{Name: "scan_completed"},
+		{Name: "scan_started", Level: "loud"},
+		{Name: "scan_started", Level: "dpanic"},
+		{Name: "scan_started", Level: "panic"},
+		{Name: "scan_started", Level: "fatal"},
+	} {
+		if err := c.Register(def); err == nil {
+			t.Errorf("expected %+v to be rejected", def)
+		}
+	}
+
+	// A failing batch registers nothing.
+	if err := c.Register(EventDef{Name: "scan_started"}, EventDef{Name: "Bad Name"}); err == nil {
+		t.Fatal("expected the batch to be rejected")
+	}
+	if _, ok := c.Lookup("scan_started"); ok {
+		t.Error("definitions before the failing one must not stay registered")
+	}
+}
+
+func TestCatalog_ExportJSON(t *testing.T) {
+	data, err := testCatalog(t).ExportJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Events []EventDef `json:"events"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Events) != 4 || out.Events[0].Name != "event_catalog_violation" || out.Events[3].Name != "scan_completed" {
+		t.Errorf("unexpected export: %s", data)
+	}
+}
+
+func TestLogger_CatalogWarnMode(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithCatalog(testCatalog(t), ValidateWarn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	l.Info(ctx, "scan_completed", "done", ports.Field{Key: "scan_id", Value: "s-1"})
+	l.Info(ctx, "scan_completed", "done")
+	l.Info(ctx, "agent_strat", "typo")
+	_ = l.Sync()
+
+	violations := 0
+	for _, e := range readEntries(t, filepath.Join(dir, "app.log")) {
+		if e["event"] == "event_catalog_violation" {
+			violations++
+		}
+	}
+	if violations != 2 {
+		t.Errorf("expected 2 violations, got %d", violations)
+	}
+}
+
+func TestLogger_CatalogStrictModeReportsViolations(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithCatalog(testCatalog(t), ValidateStrict))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info(context.Background(), "unregistered_event", "boom")
+	l.Info(context.Background(), "unregistered_event", "boom")
+	_ = l.Sync()
+
+	err = l.CatalogError()
+	if err == nil || !strings.Contains(err.Error(), "unregistered_event") || strings.Count(err.Error(), "\n") != 0 {
+		t.Errorf("expected the violation recorded once, got %v", err)
+	}
+	entries := readEntries(t, filepath.Join(dir, "error.log"))
+	if len(entries) != 2 || entries[0]["event"] != "event_catalog_violation" {
+		t.Errorf("expected the violations logged at error level, got %v", entries)
+	}
+	if n := len(readEntries(t, filepath.Join(dir, "app.log"))); n != 2 {
+		t.Errorf("the offending entries must still be logged, got %d", n)
+	}
+}
+
+func TestLogger_EmitUsesDefaultLevel(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithCatalog(testCatalog(t), ValidateStrict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Emit(context.Background(), "operation_failed", "scanner crashed")
+	_ = l.Sync()
+
+	entries := readEntries(t, filepath.Join(dir, "error.log"))
+	if len(entries) != 1 || entries[0]["level"] != "error" {
+		t.Errorf("expected one error entry, got %v", entries)
+	}
+}
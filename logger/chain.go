@@ -0,0 +1,339 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// genesisHash is the prev link of the very first entry of a chain.
+var genesisHash = string(bytes.Repeat([]byte("0"), sha256.Size*2))
+
+// chainHashSuffix is the fixed-width tail appended to every chained line:
+// `,"chain_hash":"<64 hex chars>"}`.
+const (
+	chainHashKey    = `,"chain_hash":"`
+	chainSuffixSize = len(chainHashKey) + sha256.Size*2 + len(`"}`)
+)
+
+// chainWriter makes a stream tamper-evident. Every encoded entry receives a
+// sequence number, the hash of the previous entry and its own hash computed over
+// the entry including both, so edits, deletions and reordering break the chain.
+// The state lives in memory and therefore survives lumberjack rotations.
+type chainWriter struct {
+	mu   sync.Mutex
+	out  zapcore.WriteSyncer
+	key  []byte
+	seq  uint64
+	prev string
+}
+
+// newChainWriter resumes the chain from the last entry already written to the
+// stream. Unparsable entries after it, such as a write torn by a crash, are
+// stepped over with a restart marker the verifier accepts.
+func newChainWriter(out zapcore.WriteSyncer, key []byte, logDir string, filename string) (*chainWriter, error) {
+	tail, err := lastChainLink(logDir, filename)
+	if err != nil {
+		return nil, err
+	}
+	w := &chainWriter{
+		out:  out,
+		key:  key,
+		seq:  tail.link.Seq,
+		prev: tail.link.Hash,
+	}
+	if tail.skipped == 0 {
+		return w, nil
+	}
+
+	if tail.unterminated != "" {
+		if err := terminateLine(tail.unterminated); err != nil {
+			return nil, err
+		}
+	}
+	marker := fmt.Sprintf(`{"level":"warn","timestamp":%q,"msg":"audit chain resumed after unparsable entries","event":%q,"chain_restart":%d}`,
+		time.Now().Format(entryTimeFormat), chainRestartEvent, tail.skipped)
+	if _, err := w.Write([]byte(marker)); err != nil {
+		return nil, fmt.Errorf("audit chain: failed to write restart marker: %w", err)
+	}
+	return w, nil
+}
+
+// Write expects exactly one JSON-encoded entry, which is how zap cores call it.
+func (w *chainWriter) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	if len(line) < 2 || line[len(line)-1] != '}' {
+		return 0, fmt.Errorf("audit chain: refusing to write non-JSON entry")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seq := w.seq + 1
+	body := make([]byte, 0, len(line)+chainSuffixSize+96)
+	body = append(body, line[:len(line)-1]...)
+	body = append(body, `,"chain_seq":`...)
+	body = strconv.AppendUint(body, seq, 10)
+	body = append(body, `,"chain_prev":"`...)
+	body = append(body, w.prev...)
+	body = append(body, `"}`...)
+
+	hash := chainHash(w.key, body)
+
+	out := append(body[:len(body)-1], chainHashKey...)
+	out = append(out, hash...)
+	out = append(out, "\"}\n"...)
+
+	if _, err := w.out.Write(out); err != nil {
+		return 0, err
+	}
+	w.seq = seq
+	w.prev = hash
+	return len(p), nil
+}
+
+func (w *chainWriter) Sync() error {
+	return w.out.Sync()
+}
+
+func chainHash(key []byte, body []byte) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chainRestartEvent is the event of the entry a writer chains after
+// unparsable entries; its chain_restart field counts the entries skipped.
+const chainRestartEvent = "audit_chain_restart"
+
+// chainLink is the chain metadata carried by every chained entry.
+type chainLink struct {
+	Seq  uint64
+	Prev string
+	Hash string
+	// Restart is the number of unparsable entries a restart marker steps over.
+	Restart int
+}
+
+var errNotChained = errors.New("entry carries no chain fields")
+
+// parseChainLink splits a chained line into the hashed body and its link fields.
+func parseChainLink(line []byte) ([]byte, chainLink, error) {
+	n := len(line)
+	if n < chainSuffixSize || string(line[n-chainSuffixSize:n-chainSuffixSize+len(chainHashKey)]) != chainHashKey {
+		return nil, chainLink{}, errNotChained
+	}
+
+	hash := string(line[n-2-sha256.Size*2 : n-2])
+	body := make([]byte, 0, n-chainSuffixSize+1)
+	body = append(body, line[:n-chainSuffixSize]...)
+	body = append(body, '}')
+
+	var fields struct {
+		Seq     *uint64 `json:"chain_seq"`
+		Prev    string  `json:"chain_prev"`
+		Restart int     `json:"chain_restart"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, chainLink{}, fmt.Errorf("malformed entry: %w", err)
+	}
+	if fields.Seq == nil {
+		return nil, chainLink{}, errNotChained
+	}
+	return body, chainLink{Seq: *fields.Seq, Prev: fields.Prev, Hash: hash, Restart: fields.Restart}, nil
+}
+
+// chainTail is where a restarted writer resumes the chain of a stream.
+type chainTail struct {
+	link chainLink
+	// skipped counts the unparsable entries written after link.
+	skipped int
+	// unterminated is the active file when its last line lacks a newline.
+	unterminated string
+}
+
+// lastChainLink finds the newest chained entry of a stream so a restarted
+// process continues the existing chain instead of starting a new one. A
+// stream without any chained entry is legacy content and starts a fresh
+// chain after it.
+func lastChainLink(logDir string, filename string) (chainTail, error) {
+	files, err := StreamFiles(logDir, filename)
+	if err != nil {
+		return chainTail{}, err
+	}
+
+	var tail chainTail
+	for i := len(files) - 1; i >= 0; i-- {
+		link, found, after, err := lastLinkIn(files[i])
+		if err != nil {
+			return chainTail{}, err
+		}
+		if found {
+			tail.link = link
+		}
+
+		tail.skipped += after
+		if after > 0 && filepath.Base(files[i]) == filename {
+			if open, err := endsUnterminated(files[i]); err != nil {
+				return chainTail{}, err
+			} else if open {
+				tail.unterminated = files[i]
+			}
+		}
+		if found {
+			return tail, nil
+		}
+	}
+	return chainTail{link: chainLink{Hash: genesisHash}}, nil
+}
+
+// lastLinkIn returns the newest chained entry of one stream file and the
+// number of lines after it. Plain files are read backwards from the end, so
+// only their tail is read; gzipped backups are scanned in full.
+func lastLinkIn(path string) (link chainLink, found bool, after int, err error) {
+	visit := func(line []byte) bool {
+		if _, l, perr := parseChainLink(line); perr == nil {
+			link, found = l, true
+			return false
+		}
+		after++
+		return true
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		err = eachLineBackward(path, visit)
+		return link, found, after, err
+	}
+
+	err = eachLine(path, func(_ int, line []byte) error {
+		if _, l, perr := parseChainLink(line); perr == nil {
+			link, found, after = l, true, 0
+		} else {
+			after++
+		}
+		return nil
+	})
+	return link, found, after, err
+}
+
+// tailBlockSize is how much eachLineBackward reads per step.
+const tailBlockSize = 64 << 10
+
+// eachLineBackward calls fn for the non-empty lines of a plain file, newest
+// first, until fn returns false.
+func eachLineBackward(path string, fn func(line []byte) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	off := info.Size()
+	buf := make([]byte, tailBlockSize)
+	// rest holds the read bytes not yet emitted: the start of the file
+	// region read so far, up to its first newline.
+	var rest []byte
+	for off > 0 {
+		n := int64(len(buf))
+		if off < n {
+			n = off
+		}
+		off -= n
+		if _, err := f.ReadAt(buf[:n], off); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		rest = append(append(make([]byte, 0, int(n)+len(rest)), buf[:n]...), rest...)
+
+		for i := bytes.LastIndexByte(rest, '\n'); i >= 0; i = bytes.LastIndexByte(rest, '\n') {
+			line := bytes.TrimRight(rest[i+1:], "\r")
+			rest = rest[:i]
+			if len(line) > 0 && !fn(line) {
+				return nil
+			}
+		}
+	}
+	if line := bytes.TrimRight(rest, "\r"); len(line) > 0 {
+		fn(line)
+	}
+	return nil
+}
+
+// endsUnterminated reports whether the last byte of a plain file is not a
+// newline.
+func endsUnterminated(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// terminateLine ends the torn last line of path so the next entry starts on
+// a line of its own.
+func terminateLine(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("audit chain: failed to terminate torn entry: %w", err)
+	}
+	_, err = f.Write([]byte("\n"))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// eachLine calls fn for every non-empty line of a (possibly gzipped) stream file.
+func eachLine(path string, fn func(lineNo int, line []byte) error) error {
+	rc, err := OpenStreamFile(path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadBytes('\n')
+		if trimmed := bytes.TrimRight(line, "\r\n"); len(trimmed) > 0 {
+			if ferr := fn(lineNo, trimmed); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+}
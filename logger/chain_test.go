@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeAuditEntries(t *testing.T, dir string, key []byte, n int) {
+	t.Helper()
+	l, err := New("test", "info", WithLogDir(dir), WithAuditChainKey(key))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		l.LogAudit(context.Background(), "resource_updated", "alice", "update", "scan-42")
+	}
+	_ = l.Sync()
+}
+
+func TestAuditChain_VerifiesAcrossRestartAndCompressedBackup(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("chain-key")
+
+	writeAuditEntries(t, dir, key, 3)
+
+	// Simulate a lumberjack rotation with compression.
+	active := filepath.Join(dir, "audit.log")
+	data, err := os.ReadFile(active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(data)
+	zw.Close()
+	// Lumberjack prunes backups older than MaxAge, so the name must be recent.
+	backup := "audit-" + time.Now().UTC().Add(-time.Minute).Format(backupTimeFormat) + ".log.gz"
+	os.WriteFile(filepath.Join(dir, backup), gz.Bytes(), 0644)
+	os.Remove(active)
+
+	writeAuditEntries(t, dir, key, 2)
+
+	report, err := VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break != nil {
+		t.Fatalf("unexpected break: %v", report.Break)
+	}
+	if report.Entries != 5 || report.FirstSeq != 1 || report.LastSeq != 5 {
+		t.Errorf("expected 5 entries seq 1..5, got %d entries seq %d..%d", report.Entries, report.FirstSeq, report.LastSeq)
+	}
+	if len(report.Files) != 2 {
+		t.Errorf("expected backup and active file, got %v", report.Files)
+	}
+}
+
+func TestAuditChain_DetectsModifiedEntry(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("chain-key")
+	writeAuditEntries(t, dir, key, 3)
+
+	active := filepath.Join(dir, "audit.log")
+	data, _ := os.ReadFile(active)
+	lines := strings.SplitAfter(string(data), "\n")
+	lines[1] = strings.Replace(lines[1], `"actor":"alice"`, `"actor":"mallory"`, 1)
+	os.WriteFile(active, []byte(strings.Join(lines, "")), 0644)
+
+	report, err := VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break == nil || report.Break.Line != 2 || report.Break.Seq != 2 {
+		t.Fatalf("expected break at line 2 seq 2, got %+v", report.Break)
+	}
+}
+
+func TestAuditChain_DetectsMissingEntry(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("chain-key")
+	writeAuditEntries(t, dir, key, 3)
+
+	active := filepath.Join(dir, "audit.log")
+	data, _ := os.ReadFile(active)
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(active, []byte(lines[0]+lines[2]), 0644)
+
+	report, err := VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break == nil || report.Break.Seq != 2 || !strings.Contains(report.Break.Reason, "missing link") {
+		t.Fatalf("expected missing link at seq 2, got %+v", report.Break)
+	}
+}
+
+func TestAuditChain_WrongKeyFails(t *testing.T) {
+	dir := t.TempDir()
+	writeAuditEntries(t, dir, []byte("chain-key"), 1)
+
+	report, err := VerifyAuditChain(dir, []byte("other-key"))
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break == nil {
+		t.Fatal("expected verification with the wrong key to fail")
+	}
+}
+
+func TestAuditChain_RestartsAfterTornTail(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("chain-key")
+	writeAuditEntries(t, dir, key, 2)
+
+	// A crash tore the last write.
+	active := filepath.Join(dir, "audit.log")
+	f, _ := os.OpenFile(active, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"level":"info","event":"resource_upd`)
+	f.Close()
+
+	report, err := VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break == nil || report.Break.Line != 3 {
+		t.Fatalf("expected the torn tail to break the chain, got %+v", report.Break)
+	}
+
+	writeAuditEntries(t, dir, key, 1)
+
+	report, err = VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break != nil {
+		t.Fatalf("unexpected break: %v", report.Break)
+	}
+	if len(report.Restarts) != 1 || report.Restarts[0].Line != 3 || report.Entries != 4 || report.LastSeq != 4 {
+		t.Errorf("expected one restart at line 3 and seq 1..4, got %+v", report)
+	}
+
+	// Dropping the torn entry afterwards no longer matches the marker.
+	data, _ := os.ReadFile(active)
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(active, []byte(lines[0]+lines[1]+strings.Join(lines[3:], "")), 0644)
+	report, _ = VerifyAuditChain(dir, key)
+	if report.Break == nil || !strings.Contains(report.Break.Reason, "restart marker") {
+		t.Errorf("expected the orphaned marker to break the chain, got %+v", report.Break)
+	}
+}
+
+func TestAuditChain_StartsAfterLegacyEntries(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("chain-key")
+	os.WriteFile(filepath.Join(dir, "audit.log"), []byte("{\"event\":\"legacy\"}\n{\"event\":\"legacy\"}\n"), 0644)
+
+	writeAuditEntries(t, dir, key, 2)
+
+	report, err := VerifyAuditChain(dir, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if report.Break != nil || report.FirstSeq != 1 || report.Entries != 2 || len(report.Restarts) != 1 {
+		t.Errorf("expected a chain from genesis after the legacy entries, got %+v (break %v)", report, report.Break)
+	}
+}
+
+func TestEachLineBackward_CrossesBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	var want []string
+	var data strings.Builder
+	for i := 0; data.Len() < 3*tailBlockSize; i++ {
+		line := fmt.Sprintf(`{"n":%d,"pad":%q}`, i, strings.Repeat("x", i%300))
+		want = append(want, line)
+		data.WriteString(line + "\n")
+	}
+	if err := os.WriteFile(path, []byte(data.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if err := eachLineBackward(path, func(line []byte) bool {
+		got = append(got, string(line))
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(got))
+	}
+	for i := range got {
+		if got[i] != want[len(want)-1-i] {
+			t.Fatalf("line %d: got %q, want %q", i, got[i], want[len(want)-1-i])
+		}
+	}
+}
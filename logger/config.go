@@ -8,17 +8,140 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Stream identifies one of the log files a Logger writes to.
+type Stream string
+
+const (
+	StreamApp      Stream = "app"
+	StreamError    Stream = "error"
+	StreamAudit    Stream = "audit"
+	StreamSecurity Stream = "security"
+)
+
+// lossless reports whether entries of the stream must never be dropped.
+func (s Stream) lossless() bool {
+	return s == StreamAudit || s == StreamSecurity
+}
+
+// Option customizes a Logger at construction time.
+type Option func(*options)
+
+type options struct {
+	logDir   string
+	auditKey []byte
+	redactor *Redactor
+	sampling map[Stream]SamplingConfig
+	sinks    map[Stream][]Sink
+	async    map[Stream]AsyncConfig
+
+	formatted  map[Stream][]formattedSink
+	siemHeader SIEMHeader
+
+	catalog    *Catalog
+	validation ValidationMode
+
+	tenants *TenantConfig
+}
+
+func defaultOptions() options {
+	return options{
+		logDir:   "logs",
+		redactor: NewRedactor(RedactionConfig{}),
+		sampling: make(map[Stream]SamplingConfig),
+		sinks:    make(map[Stream][]Sink),
+		async:    make(map[Stream]AsyncConfig),
+
+		formatted: make(map[Stream][]formattedSink),
+	}
+}
+
+func (o options) validate() error {
+	for stream := range o.sampling {
+		if stream.lossless() {
+			return fmt.Errorf("sampling is not allowed on the %s stream", stream)
+		}
+	}
+	for stream, cfg := range o.async {
+		if stream.lossless() && cfg.Overflow != OverflowBlock {
+			return fmt.Errorf("the %s stream must use OverflowBlock", stream)
+		}
+	}
+	if o.tenants != nil {
+		for _, stream := range o.tenants.Streams {
+			if stream != StreamAudit && stream != StreamSecurity {
+				return fmt.Errorf("tenant routing is not supported on the %s stream", stream)
+			}
+		}
+	}
+	return nil
+}
+
+// WithLogDir overrides the directory the log streams are written to (default "logs").
+func WithLogDir(dir string) Option {
+	return func(o *options) {
+		o.logDir = dir
+	}
+}
+
+// WithAuditChainKey enables HMAC-SHA256 chaining of audit entries.
+// Without a key the audit chain falls back to plain SHA-256 links.
+func WithAuditChainKey(key []byte) Option {
+	return func(o *options) {
+		o.auditKey = key
+	}
+}
+
+// WithRedactor replaces the default secret redactor. Passing nil disables
+// redaction entirely, which should only be done in tests.
+func WithRedactor(r *Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithSampling throttles repeated events on the app or error stream.
+// The audit and security streams are never sampled; New rejects them.
+func WithSampling(stream Stream, cfg SamplingConfig) Option {
+	return func(o *options) {
+		o.sampling[stream] = cfg
+	}
+}
+
+// WithAsync writes the given stream from a background goroutine through a
+// bounded buffer. Logger.Sync drains the buffer before returning.
+func WithAsync(stream Stream, cfg AsyncConfig) Option {
+	return func(o *options) {
+		o.async[stream] = cfg
+	}
+}
+
+// RotationPolicy controls when a stream file is rotated and how long its
+// backups are kept.
+type RotationPolicy struct {
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+// DefaultRotationPolicy applies to the shared stream files.
+var DefaultRotationPolicy = RotationPolicy{MaxSizeMB: 50, MaxBackups: 10, MaxAgeDays: 30, Compress: true}
+
 // createRotator simplifies setting up Lumberjack rotators for different log files.
 func createRotator(logDir string, filename string) (*lumberjack.Logger, error) {
+	return newRotator(logDir, filename, DefaultRotationPolicy)
+}
+
+func newRotator(logDir string, filename string, policy RotationPolicy) (*lumberjack.Logger, error) {
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create logs directory: %w", err)
 	}
 
 	return &lumberjack.Logger{
 		Filename:   filepath.Join(logDir, filename),
-		MaxSize:    50, // MB
-		MaxBackups: 10,
-		MaxAge:     30, // days
-		Compress:   true,
+		MaxSize:    policy.MaxSizeMB,
+		MaxBackups: policy.MaxBackups,
+		MaxAge:     policy.MaxAgeDays,
+		Compress:   policy.Compress,
 	}, nil
 }
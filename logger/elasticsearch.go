@@ -0,0 +1,333 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ElasticsearchConfig configures an ElasticsearchSink.
+type ElasticsearchConfig struct {
+	// URL is the cluster base URL, e.g. "https://es.internal:9200".
+	URL string
+	// Index receives the documents; data streams are supported.
+	Index string
+
+	Username string
+	Password string
+	APIKey   string
+
+	// BatchSize is the number of documents per _bulk request. Default 500.
+	BatchSize int
+	// FlushInterval bounds how long a partial batch waits. Default 1s.
+	FlushInterval time.Duration
+	// QueueSize is the number of buffered documents. When the queue is full
+	// Write blocks, pushing back on the producer. Default 10000.
+	QueueSize int
+	// MaxRetries for retryable failures (network, 429, 5xx). Default 3.
+	MaxRetries int
+	// RetryBackoff is the first retry delay, doubled on every attempt. Default 200ms.
+	RetryBackoff time.Duration
+
+	HTTPClient *http.Client
+}
+
+// ElasticsearchStats counts the documents handled by an ElasticsearchSink.
+type ElasticsearchStats struct {
+	Indexed int64
+	Failed  int64
+	Retries int64
+	// Unconfirmed counts documents of accepted (2xx) requests whose response
+	// could not be decoded. They are not resent, since Elasticsearch may have
+	// indexed them already.
+	Unconfirmed int64
+	// LastError is the most recent request or response error, if any.
+	LastError error
+}
+
+// ElasticsearchSink ships entries to Elasticsearch through the _bulk API.
+type ElasticsearchSink struct {
+	cfg    ElasticsearchConfig
+	action []byte
+
+	queue chan []byte
+	flush chan chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+
+	indexed atomic.Int64
+	failed      atomic.Int64
+	retries     atomic.Int64
+	unconfirmed atomic.Int64
+
+	errMu   sync.Mutex
+	lastErr error
+}
+
+// NewElasticsearchSink starts the background batcher of a new sink.
+func NewElasticsearchSink(cfg ElasticsearchConfig) (*ElasticsearchSink, error) {
+	if cfg.URL == "" || cfg.Index == "" {
+		return nil, fmt.Errorf("elasticsearch sink requires a URL and an index")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	action, err := json.Marshal(map[string]map[string]string{"create": {"_index": cfg.Index}})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ElasticsearchSink{
+		cfg:    cfg,
+		action: append(action, '\n'),
+		queue:  make(chan []byte, cfg.QueueSize),
+		flush:  make(chan chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// Write queues one encoded entry, blocking while the queue is full.
+func (s *ElasticsearchSink) Write(p []byte) (int, error) {
+	doc := bytes.TrimRight(p, "\n")
+	cp := make([]byte, len(doc))
+	copy(cp, doc)
+
+	select {
+	case <-s.done:
+		return 0, fmt.Errorf("elasticsearch sink is closed")
+	default:
+	}
+	select {
+	case s.queue <- cp:
+		return len(p), nil
+	case <-s.done:
+		return 0, fmt.Errorf("elasticsearch sink is closed")
+	}
+}
+
+// Sync blocks until every queued entry has been sent or given up on.
+func (s *ElasticsearchSink) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case s.flush <- ack:
+		<-ack
+	case <-s.done:
+	}
+	return nil
+}
+
+// Close flushes the queue and stops the batcher. It is safe to call twice.
+func (s *ElasticsearchSink) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+	return nil
+}
+
+// Stats returns a snapshot of the sink counters.
+func (s *ElasticsearchSink) Stats() ElasticsearchStats {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return ElasticsearchStats{
+		Indexed:     s.indexed.Load(),
+		Failed:      s.failed.Load(),
+		Retries:     s.retries.Load(),
+		Unconfirmed: s.unconfirmed.Load(),
+		LastError:   s.lastErr,
+	}
+}
+
+func (s *ElasticsearchSink) setError(err error) {
+	s.errMu.Lock()
+	s.lastErr = err
+	s.errMu.Unlock()
+}
+
+func (s *ElasticsearchSink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.cfg.BatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = batch[:0]
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case doc := <-s.queue:
+				batch = append(batch, doc)
+				if len(batch) >= s.cfg.BatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case doc := <-s.queue:
+			batch = append(batch, doc)
+			if len(batch) >= s.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-s.flush:
+			drain()
+			close(ack)
+		case <-s.done:
+			drain()
+			return
+		}
+	}
+}
+
+// send delivers a batch, retrying the whole request on transport errors and
+// only the rejected documents on retryable per-item failures.
+func (s *ElasticsearchSink) send(batch [][]byte) {
+	pending := batch
+	backoff := s.cfg.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := s.bulk(pending)
+		if err == nil && len(retry) == 0 {
+			return
+		}
+		if err == nil {
+			pending = retry
+		} else {
+			s.setError(err)
+		}
+
+		if attempt >= s.cfg.MaxRetries {
+			s.failed.Add(int64(len(pending)))
+			return
+		}
+		s.retries.Add(1)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			// Shutting down: one final attempt without waiting.
+			if retry, err := s.bulk(pending); err != nil {
+				s.setError(err)
+				s.failed.Add(int64(len(pending)))
+			} else {
+				s.failed.Add(int64(len(retry)))
+			}
+			return
+		}
+		backoff *= 2
+	}
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+	} `json:"items"`
+}
+
+// bulk performs one _bulk request and returns the documents worth retrying.
+func (s *ElasticsearchSink) bulk(docs [][]byte) ([][]byte, error) {
+	var body bytes.Buffer
+	for _, doc := range docs {
+		body.Write(s.action)
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case s.cfg.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+s.cfg.APIKey)
+	case s.cfg.Username != "":
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("elasticsearch bulk returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		s.failed.Add(int64(len(docs)))
+		return nil, nil
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		// The request was accepted; resending would duplicate documents.
+		s.unconfirmed.Add(int64(len(docs)))
+		s.setError(fmt.Errorf("invalid elasticsearch bulk response: %w", err))
+		return nil, nil
+	}
+	if !result.Errors {
+		s.indexed.Add(int64(len(docs)))
+		return nil, nil
+	}
+
+	var retry [][]byte
+	for i, item := range result.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, r := range item {
+			switch {
+			case r.Status < 300:
+				s.indexed.Add(1)
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				retry = append(retry, docs[i])
+			default:
+				s.failed.Add(1)
+			}
+		}
+	}
+	return retry, nil
+}
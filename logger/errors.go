@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/SecDuckOps/shared/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxErrorChain bounds the rendered chain against pathological or cyclic
+// Unwrap implementations.
+const maxErrorChain = 32
+
+// errorLink is one layer of a rendered error chain.
+type errorLink struct {
+	Depth   int
+	Type    string
+	Code    types.ErrorCode
+	Message string
+	Context map[string]interface{}
+
+	appErr *types.AppError
+}
+
+// errorChain flattens err depth-first, outermost layer first. Each branch of
+// an errors.Join tree follows its parent one level deeper; the join node
+// itself carries no message and is not rendered.
+func errorChain(err error) []errorLink {
+	var links []errorLink
+	var walk func(err error, depth int)
+	walk = func(err error, depth int) {
+		if err == nil || len(links) >= maxErrorChain {
+			return
+		}
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walk(e, depth+1)
+			}
+			return
+		}
+
+		link := errorLink{Depth: depth, Type: fmt.Sprintf("%T", err)}
+		var next error
+		if appErr, ok := err.(*types.AppError); ok {
+			link.Code = appErr.Code
+			link.Message = appErr.Message
+			link.Context = appErr.Context
+			link.appErr = appErr
+			next = appErr.Cause
+		} else {
+			link.Message = err.Error()
+			if wrapper, ok := err.(interface{ Unwrap() error }); ok {
+				next = wrapper.Unwrap()
+			}
+			// fmt.Errorf("op: %w") repeats the inner message; keep the layer's own text.
+			if next != nil {
+				link.Message = strings.TrimSuffix(link.Message, ": "+next.Error())
+			}
+		}
+		links = append(links, link)
+		walk(next, depth+1)
+	}
+	walk(err, 0)
+	return links
+}
+
+// specificAppError returns the AppError whose code best describes err: the
+// outermost one with a code other than ErrCodeInternal, since wrapping a
+// not-found in a generic internal error shouldn't turn it into an error-level
+// entry. Without such a code the outermost AppError is returned.
+func specificAppError(err error) *types.AppError {
+	var first *types.AppError
+	var walk func(err error, depth int) *types.AppError
+	walk = func(err error, depth int) *types.AppError {
+		if err == nil || depth > maxErrorChain {
+			return nil
+		}
+		if appErr, ok := err.(*types.AppError); ok {
+			if first == nil {
+				first = appErr
+			}
+			if appErr.Code != types.ErrCodeInternal {
+				return appErr
+			}
+			return walk(appErr.Cause, depth+1)
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				if found := walk(inner, depth+1); found != nil {
+					return found
+				}
+			}
+		case interface{ Unwrap() error }:
+			return walk(e.Unwrap(), depth+1)
+		}
+		return nil
+	}
+	if found := walk(err, 0); found != nil {
+		return found
+	}
+	return first
+}
+
+// redactedErrorChain renders the chain of err with messages and contexts
+// redacted once, and returns the index of the link of specificAppError or -1.
+func redactedErrorChain(err error, r *Redactor) ([]errorLink, int) {
+	specific := specificAppError(err)
+	links := errorChain(err)
+	index := -1
+	for i := range links {
+		if specific != nil && links[i].appErr == specific && index < 0 {
+			index = i
+		}
+		links[i].Message = r.RedactString(links[i].Message)
+		if len(links[i].Context) > 0 {
+			links[i].Context, _ = r.Redact("error_context", links[i].Context).(map[string]interface{})
+		}
+	}
+	return links, index
+}
+
+type errorChainMarshaler []errorLink
+
+func (m errorChainMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, link := range m {
+		link := link
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			oe.AddInt("depth", link.Depth)
+			oe.AddString("type", link.Type)
+			if link.Code != "" {
+				oe.AddString("code", string(link.Code))
+			}
+			oe.AddString("message", link.Message)
+			if len(link.Context) > 0 {
+				return oe.AddReflected("context", link.Context)
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorZapFields describes err for ErrorErr: the specific AppError's code,
+// message and context at the top level plus the full error_chain.
+func (l *Logger) errorZapFields(err error) []zap.Field {
+	links, specific := redactedErrorChain(err, l.redactor)
+
+	var fields []zap.Field
+	if specific >= 0 {
+		link := links[specific]
+		fields = append(fields,
+			zap.String("error_code", string(link.Code)),
+			zap.String("error_message", link.Message),
+			zap.Reflect("error_context", link.Context),
+			zap.Time("error_timestamp", link.appErr.Timestamp),
+		)
+		if link.appErr.Cause != nil {
+			fields = append(fields, zap.String("cause", l.redactor.RedactString(link.appErr.Cause.Error())))
+		}
+	} else {
+		fields = append(fields,
+			zap.String("error_code", string(types.ErrCodeInternal)),
+			zap.String("error", l.redactor.RedactString(err.Error())),
+		)
+	}
+	return append(fields, zap.Array("error_chain", errorChainMarshaler(links)))
+}
+
+// errorSlogAttrs is errorZapFields for SlogLogger.
+func (s *SlogLogger) errorSlogAttrs(err error) []slog.Attr {
+	links, specific := redactedErrorChain(err, s.redactor)
+
+	var attrs []slog.Attr
+	if specific >= 0 {
+		link := links[specific]
+		attrs = append(attrs,
+			slog.String("error_code", string(link.Code)),
+			slog.String("error_message", link.Message),
+			slog.Any("error_context", link.Context),
+			slog.Time("error_timestamp", link.appErr.Timestamp),
+		)
+		if link.appErr.Cause != nil {
+			attrs = append(attrs, slog.String("cause", s.redactor.RedactString(link.appErr.Cause.Error())))
+		}
+	} else {
+		attrs = append(attrs,
+			slog.String("error_code", string(types.ErrCodeInternal)),
+			slog.String("error", s.redactor.RedactString(err.Error())),
+		)
+	}
+
+	chain := make([]map[string]interface{}, len(links))
+	for i, link := range links {
+		m := map[string]interface{}{"depth": link.Depth, "type": link.Type, "message": link.Message}
+		if link.Code != "" {
+			m["code"] = string(link.Code)
+		}
+		if len(link.Context) > 0 {
+			m["context"] = link.Context
+		}
+		chain[i] = m
+	}
+	return append(attrs, slog.Any("error_chain", chain))
+}
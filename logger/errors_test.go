@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/SecDuckOps/shared/types"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestErrorChain_WrappedAndJoined(t *testing.T) {
+	root := types.New(types.ErrCodeNotFound, "scan missing").WithContext("scan_id", "s-1")
+	wrapped := fmt.Errorf("load scan: %w", types.Wrap(root, types.ErrCodeInternal, "repository failed"))
+	joined := errors.Join(wrapped, errors.New("cleanup failed"))
+
+	links := errorChain(joined)
+	want := []errorLink{
+		{Depth: 1, Message: "load scan"},
+		{Depth: 2, Code: types.ErrCodeInternal, Message: "repository failed"},
+		{Depth: 3, Code: types.ErrCodeNotFound, Message: "scan missing"},
+		{Depth: 1, Message: "cleanup failed"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links, got %+v", len(want), links)
+	}
+	for i, w := range want {
+		if links[i].Depth != w.Depth || links[i].Code != w.Code || links[i].Message != w.Message {
+			t.Errorf("link %d: got %+v, want %+v", i, links[i], w)
+		}
+	}
+	if links[2].Context["scan_id"] != "s-1" {
+		t.Errorf("expected root context, got %v", links[2].Context)
+	}
+}
+
+func TestErrorLevel_UsesMostSpecificCode(t *testing.T) {
+	notFound := types.New(types.ErrCodeNotFound, "missing")
+	for _, tc := range []struct {
+		name string
+		err  error
+		want zapcore.Level
+	}{
+		{"direct", notFound, zapcore.WarnLevel},
+		{"fmt wrapped", fmt.Errorf("op: %w", notFound), zapcore.WarnLevel},
+		{"behind internal", types.Wrap(notFound, types.ErrCodeInternal, "failed"), zapcore.WarnLevel},
+		{"joined", errors.Join(errors.New("x"), notFound), zapcore.WarnLevel},
+		{"specific outer wins", types.Wrap(notFound, types.ErrCodeAuthFailed, "denied"), zapcore.ErrorLevel},
+		{"plain", errors.New("boom"), zapcore.ErrorLevel},
+	} {
+		if got := ErrorLevel(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestErrorErr_RendersErrorChain(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := types.New(types.ErrCodeInvalidInput, "bad token").WithContext("api_key", "sk-live-1234567890")
+	l.ErrorErr(context.Background(), "operation_failed", fmt.Errorf("handle request: %w", root), "request rejected")
+	_ = l.Sync()
+
+	entries := readEntries(t, filepath.Join(dir, "app.log"))
+	if len(entries) != 1 {
+		t.Fatalf("expected the wrapped invalid input in app.log, got %v", entries)
+	}
+	e := entries[0]
+	if e["level"] != "warn" || e["error_code"] != string(types.ErrCodeInvalidInput) || e["error_message"] != "bad token" {
+		t.Errorf("unexpected top-level error fields %v", e)
+	}
+	chain, _ := e["error_chain"].([]interface{})
+	if len(chain) != 2 {
+		t.Fatalf("expected two chain links, got %v", e["error_chain"])
+	}
+	outer, _ := chain[0].(map[string]interface{})
+	inner, _ := chain[1].(map[string]interface{})
+	if outer["message"] != "handle request" || outer["type"] != "*fmt.wrapError" {
+		t.Errorf("unexpected outer link %v", outer)
+	}
+	ctx, _ := inner["context"].(map[string]interface{})
+	if inner["code"] != string(types.ErrCodeInvalidInput) || ctx["api_key"] == "sk-live-1234567890" {
+		t.Errorf("unexpected inner link %v", inner)
+	}
+}
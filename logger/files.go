@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat mirrors the timestamp lumberjack embeds in rotated file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// StreamFiles returns every file of a log stream in logDir, oldest first:
+// the rotated backups (plain or gzipped) followed by the active file.
+func StreamFiles(logDir string, filename string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	// Keyed by backup timestamp so a backup caught mid-compression is listed once.
+	backups := make(map[string]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			continue
+		}
+		ts, ok := backupStamp(filename, name)
+		if !ok {
+			continue
+		}
+
+		compressed := strings.HasSuffix(name, ".gz")
+		if existing, ok := backups[ts]; ok && !strings.HasSuffix(existing, ".gz") && compressed {
+			continue
+		}
+		backups[ts] = filepath.Join(logDir, name)
+	}
+
+	stamps := make([]string, 0, len(backups))
+	for ts := range backups {
+		stamps = append(stamps, ts)
+	}
+	sort.Strings(stamps)
+
+	files := make([]string, 0, len(stamps)+1)
+	for _, ts := range stamps {
+		files = append(files, backups[ts])
+	}
+
+	active := filepath.Join(logDir, filename)
+	if _, err := os.Stat(active); err == nil {
+		files = append(files, active)
+	}
+	return files, nil
+}
+
+// BackupTime returns the rotation time lumberjack embedded in the name of a
+// backup of the stream file filename; false if path is not such a backup.
+func BackupTime(filename, path string) (time.Time, bool) {
+	ts, ok := backupStamp(filename, filepath.Base(path))
+	if !ok {
+		return time.Time{}, false
+	}
+	t, _ := time.Parse(backupTimeFormat, ts)
+	return t, true
+}
+
+// backupStamp extracts the timestamp from a backup name such as
+// "audit-2006-01-02T15-04-05.000.log.gz".
+func backupStamp(filename, name string) (string, bool) {
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filename, ext) + "-"
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+	ts := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+	if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+		return "", false
+	}
+	return ts, true
+}
+
+// OpenStreamFile opens a stream file for reading, transparently decompressing gzipped backups.
+func OpenStreamFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open compressed log %s: %w", path, err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
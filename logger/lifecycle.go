@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ErrClosed is returned by lifecycle methods called after Close.
+var ErrClosed = errors.New("logger is closed")
+
+// closeGuard discards writes once the logger is closed, so late entries
+// neither recreate files behind a closed rotator nor hit closed sinks.
+type closeGuard struct {
+	out    zapcore.WriteSyncer
+	closed *atomic.Bool
+}
+
+func (g closeGuard) Write(p []byte) (int, error) {
+	if g.closed.Load() {
+		return len(p), nil
+	}
+	return g.out.Write(p)
+}
+
+func (g closeGuard) Sync() error {
+	if g.closed.Load() {
+		return nil
+	}
+	return g.out.Sync()
+}
+
+// Close flushes every stream and releases the asynchronous buffers, sinks
+// and log files. It is safe to call more than once and concurrently with
+// logging; entries logged after Close are discarded.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		l.lifecycle.Lock()
+		stop := l.stopSignals
+		l.stopSignals = nil
+		l.lifecycle.Unlock()
+		if stop != nil {
+			stop()
+		}
+
+		var errs []error
+		for _, s := range l.samplers {
+			s.stop()
+		}
+		// Sync drains the asynchronous buffers into files and sinks.
+		errs = append(errs, l.Sync())
+		for _, w := range l.asyncs {
+			errs = append(errs, w.close())
+		}
+
+		l.lifecycle.Lock()
+		l.closed.Store(true)
+		l.lifecycle.Unlock()
+
+		if l.tenants != nil {
+			errs = append(errs, l.tenants.close())
+		}
+		for _, s := range l.sinks {
+			errs = append(errs, s.Close())
+		}
+		for _, r := range l.rotators {
+			errs = append(errs, r.Close())
+		}
+		l.closeErr = errors.Join(errs...)
+	})
+	return l.closeErr
+}
+
+// Reopen closes the log files so the next entry of each stream reopens its
+// path. Call it after an external tool such as logrotate moved the files.
+func (l *Logger) Reopen() error {
+	return l.eachRotator((*lumberjack.Logger).Close)
+}
+
+// Rotate forces lumberjack to rotate every stream file now, keeping the
+// usual backup naming, compression and retention.
+func (l *Logger) Rotate() error {
+	return l.eachRotator((*lumberjack.Logger).Rotate)
+}
+
+func (l *Logger) eachRotator(fn func(*lumberjack.Logger) error) error {
+	l.lifecycle.Lock()
+	defer l.lifecycle.Unlock()
+	if l.closed.Load() {
+		return ErrClosed
+	}
+
+	var errs []error
+	for _, r := range l.rotators {
+		errs = append(errs, fn(r))
+	}
+	if l.tenants != nil {
+		errs = append(errs, l.tenants.eachRotator(fn))
+	}
+	return errors.Join(errs...)
+}
+
+// registerSignalStop installs the stop function of a signal hook, replacing
+// a previous hook, and makes it idempotent.
+func (l *Logger) registerSignalStop(stop func()) func() {
+	var once sync.Once
+	stopOnce := func() { once.Do(stop) }
+
+	l.lifecycle.Lock()
+	prev := l.stopSignals
+	l.stopSignals = stopOnce
+	closed := l.closed.Load()
+	l.lifecycle.Unlock()
+
+	if prev != nil {
+		prev()
+	}
+	if closed {
+		stopOnce()
+	}
+	return stopOnce
+}
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLogger_ReopenDuringConcurrentLogging(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithAsync(StreamApp, AsyncConfig{BufferSize: 16}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var logged atomic.Int64
+	stop, reopened := make(chan struct{}), make(chan struct{})
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				l.Info(ctx, "tick", "tick")
+				l.LogAudit(ctx, "resource_read", "agent", "read", "scan")
+				logged.Add(1)
+			}
+		}()
+	}
+
+	// Emulate logrotate: move the files away and reopen, repeatedly.
+	go func() {
+		defer close(reopened)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			name := filepath.Join(dir, "app.log")
+			_ = os.Rename(name, name+"."+strconv.Itoa(i))
+			if err := l.Reopen(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-reopened
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	matches, _ := filepath.Glob(filepath.Join(dir, "app.log*"))
+	for _, m := range matches {
+		total += len(readEntries(t, m))
+	}
+	if total != int(logged.Load()) {
+		t.Errorf("expected %d app entries across %d files, got %d", logged.Load(), len(matches), total)
+	}
+
+	report, err := VerifyAuditChain(dir, nil)
+	if err != nil || report.Break != nil || report.Entries != int(logged.Load()) {
+		t.Errorf("audit chain should survive reopening: %+v %v", report, err)
+	}
+}
+
+func TestLogger_CloseIsIdempotentAndFinal(t *testing.T) {
+	dir := t.TempDir()
+	sink := &memorySink{}
+	l, err := New("test", "info", WithLogDir(dir), WithSink(sink, StreamApp),
+		WithAsync(StreamApp, AsyncConfig{BufferSize: 8}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	l.Info(ctx, "agent_start", "started")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("second Close should be a no-op, got %v", err)
+	}
+
+	if n := len(readEntries(t, filepath.Join(dir, "app.log"))); n != 1 {
+		t.Errorf("Close should flush pending entries, got %d", n)
+	}
+	os.Remove(filepath.Join(dir, "app.log"))
+	l.Info(ctx, "agent_tick", "after close")
+	_ = l.Sync()
+	if _, err := os.Stat(filepath.Join(dir, "app.log")); !os.IsNotExist(err) {
+		t.Error("logging after Close must not recreate the file")
+	}
+	if len(sink.lines) != 1 {
+		t.Errorf("sink should not receive entries after Close, got %v", sink.lines)
+	}
+	if err := l.Reopen(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed from Reopen, got %v", err)
+	}
+}
+
+func TestLogger_RotateKeepsChain(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	ctx := context.Background()
+	l.LogAudit(ctx, "resource_read", "agent", "read", "scan")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.LogAudit(ctx, "resource_read", "agent", "read", "scan")
+	_ = l.Sync()
+
+	files, err := StreamFiles(dir, "audit.log")
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected a backup and the active file, got %v %v", files, err)
+	}
+	report, err := VerifyAuditChain(dir, nil)
+	if err != nil || report.Break != nil || report.Entries != 2 {
+		t.Errorf("unexpected chain report %+v %v", report, err)
+	}
+}
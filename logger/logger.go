@@ -2,11 +2,15 @@ package logger
 
 import (
 	"context"
+	"io"
+	"sync"
+	"sync/atomic"
 
 	"github.com/SecDuckOps/shared/ports"
 	"github.com/SecDuckOps/shared/types"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Logger wraps zap.Logger and provides specialized context-aware logging.
@@ -15,10 +19,39 @@ type Logger struct {
 	auditZap    *zap.Logger
 	securityZap *zap.Logger
 	service     string
+	redactor    *Redactor
+	samplers    []*sampler
+	sinks       []Sink
+	asyncs      map[Stream]*asyncWriter
+	catalog     *Catalog
+	validation  ValidationMode
+	tenants     *tenantRouter
+
+	violationsMu sync.Mutex
+	violations   []error
+
+	rotators    []*lumberjack.Logger
+	lifecycle   sync.Mutex
+	closed      atomic.Bool
+	closeOnce   sync.Once
+	closeErr    error
+	stopSignals func()
 }
 
+// entryTimeFormat is the layout zapcore.ISO8601TimeEncoder gives the
+// "timestamp" field of every entry.
+const entryTimeFormat = "2006-01-02T15:04:05.000Z0700"
+
 // New creates a new production-ready structured logger dumping to the logs directory.
-func New(service string, level string) (*Logger, error) {
+func New(service string, level string, opts ...Option) (*Logger, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
 	var zapLevel zapcore.Level
 	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
 		zapLevel = zapcore.InfoLevel
@@ -29,7 +62,7 @@ func New(service string, level string) (*Logger, error) {
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoderConfig.MessageKey = "msg" // Use 'msg' consistently for Elasticsearch
 
-	logDir := "logs"
+	logDir := o.logDir
 	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
 
 	// App and Error logs rotators
@@ -62,78 +95,152 @@ func New(service string, level string) (*Logger, error) {
 		return lvl >= zapcore.ErrorLevel
 	})
 
-	core := zapcore.NewTee(
-		zapcore.NewCore(jsonEncoder, zapcore.AddSync(generalRotator), infoLevelEnabler),
-		zapcore.NewCore(jsonEncoder, zapcore.AddSync(errorRotator), errorLevelEnabler),
-	)
-
-	return &Logger{
-		zap:         zap.New(core).With(zap.String("service", service)),
-		auditZap:    zap.New(zapcore.NewCore(jsonEncoder, zapcore.AddSync(auditRotator), zapcore.DebugLevel)).With(zap.String("service", service)),
-		securityZap: zap.New(zapcore.NewCore(jsonEncoder, zapcore.AddSync(securityRotator), zapcore.DebugLevel)).With(zap.String("service", service)),
-		service:     service,
-	}, nil
+	l := &Logger{
+		service:    service,
+		redactor:   o.redactor,
+		sinks:      uniqueSinks(o.sinks, o.formatted),
+		asyncs:     make(map[Stream]*asyncWriter),
+		catalog:    o.catalog,
+		validation: o.validation,
+		rotators:   []*lumberjack.Logger{generalRotator, errorRotator, auditRotator, securityRotator},
+	}
+
+	appWriter := l.streamWriter(StreamApp, o, generalRotator)
+	errorWriter := l.streamWriter(StreamError, o, errorRotator)
+	securityWriter := l.streamWriter(StreamSecurity, o, securityRotator)
+
+	// Audit entries are hash-chained so on-disk tampering is detectable.
+	// Sinks sit behind the chain so remote copies carry the same links.
+	auditChain, err := newChainWriter(l.streamWriter(StreamAudit, o, auditRotator), o.auditKey, logDir, "audit.log")
+	if err != nil {
+		for _, w := range l.asyncs {
+			_ = w.close()
+		}
+		return nil, err
+	}
+
+	appCore := l.sampled(StreamApp, o, l.streamCore(StreamApp, o, zapcore.NewCore(jsonEncoder, appWriter, infoLevelEnabler)))
+	errorCore := l.sampled(StreamError, o, l.streamCore(StreamError, o, zapcore.NewCore(jsonEncoder, errorWriter, errorLevelEnabler)))
+
+	l.zap = zap.New(zapcore.NewTee(appCore, errorCore))
+	l.auditZap = zap.New(l.streamCore(StreamAudit, o, zapcore.NewCore(jsonEncoder, auditChain, zapcore.DebugLevel)))
+	l.securityZap = zap.New(l.streamCore(StreamSecurity, o, zapcore.NewCore(jsonEncoder, securityWriter, zapcore.DebugLevel)))
+
+	if o.tenants != nil {
+		tenantOpts := o
+		tenantOpts.formatted = nil
+		l.tenants = newTenantRouter(*o.tenants, o, &l.closed, func(stream Stream, w zapcore.WriteSyncer, shared bool) zapcore.Core {
+			opts := tenantOpts
+			if shared {
+				opts = o
+			}
+			return l.streamCore(stream, opts, zapcore.NewCore(jsonEncoder, w, zapcore.DebugLevel))
+		})
+	}
+	return l, nil
+}
+
+// streamWriter assembles the destination of a stream: its file plus sinks,
+// optionally behind an asynchronous buffer.
+func (l *Logger) streamWriter(stream Stream, o options, file io.Writer) zapcore.WriteSyncer {
+	w := zapcore.WriteSyncer(closeGuard{out: newFanoutWriter(zapcore.AddSync(file), o.sinks[stream]), closed: &l.closed})
+	cfg, ok := o.async[stream]
+	if !ok {
+		return w
+	}
+	aw := newAsyncWriter(w, cfg)
+	l.asyncs[stream] = aw
+	return aw
+}
+
+// streamCore tees the file core of a stream with the cores of its formatted
+// sinks, which share its level, and stamps the service on all of them.
+func (l *Logger) streamCore(stream Stream, o options, file zapcore.Core) zapcore.Core {
+	cores := append([]zapcore.Core{file}, l.formattedCores(stream, o, file)...)
+	return zapcore.NewTee(cores...).With([]zapcore.Field{zap.String("service", l.service)})
+}
+
+// sampled wraps the core of a stream when sampling was configured for it.
+func (l *Logger) sampled(stream Stream, o options, core zapcore.Core) zapcore.Core {
+	cfg, ok := o.sampling[stream]
+	if !ok {
+		return core
+	}
+	sc, s := newSamplingCore(stream, cfg, core)
+	l.samplers = append(l.samplers, s)
+	return sc
 }
 
 // Debug logs a debug message with context fields.
 func (l *Logger) Debug(ctx context.Context, event string, msg string, fields ...ports.Field) {
-	zapFields := toZapFields(fields)
+	l.checkEvent(event, fields)
+	zapFields := l.toZapFields(fields)
 	zapFields = append(zapFields, zap.String("event", event))
-	l.zap.Debug(msg, l.withContextFields(ctx, zapFields)...)
+	l.zap.Debug(l.redactor.RedactString(msg), l.withContextFields(ctx, zapFields)...)
 }
 
 // Info logs an info message with context fields.
 func (l *Logger) Info(ctx context.Context, event string, msg string, fields ...ports.Field) {
-	zapFields := toZapFields(fields)
+	l.checkEvent(event, fields)
+	zapFields := l.toZapFields(fields)
 	zapFields = append(zapFields, zap.String("event", event))
-	l.zap.Info(msg, l.withContextFields(ctx, zapFields)...)
+	l.zap.Info(l.redactor.RedactString(msg), l.withContextFields(ctx, zapFields)...)
 }
 
 // ErrorErr logs an error with automatic level mapping and context extraction.
+// Wrapped and joined errors are unwrapped into an ordered error_chain.
 func (l *Logger) ErrorErr(ctx context.Context, event string, err error, msg string, fields ...ports.Field) {
-	zapFields := toZapFields(fields)
+	l.checkEvent(event, fields, "error_code")
+	zapFields := l.toZapFields(fields)
 	zapFields = append(zapFields, zap.String("event", event))
+	msg = l.redactor.RedactString(msg)
 
 	if err == nil {
 		l.zap.Error(msg, l.withContextFields(ctx, zapFields)...)
 		return
 	}
 
-	level := zapcore.ErrorLevel
-	if appErr, ok := err.(*types.AppError); ok {
-		level = mapLevel(appErr.Code)
-		zapFields = append(zapFields,
-			zap.String("error_code", string(appErr.Code)),
-			zap.String("error_message", appErr.Message),
-			zap.Reflect("error_context", appErr.Context),
-			zap.Time("error_timestamp", appErr.Timestamp),
-		)
-		if appErr.Cause != nil {
-			zapFields = append(zapFields, zap.String("cause", appErr.Cause.Error()))
-		}
-	} else {
-		zapFields = append(zapFields,
-			zap.String("error_code", string(types.ErrCodeInternal)),
-			zap.Error(err),
-		)
+	zapFields = append(zapFields, l.errorZapFields(err)...)
+	l.zap.Log(ErrorLevel(err), msg, l.withContextFields(ctx, zapFields)...)
+}
+
+// RedactionStats reports how many values each redaction rule has masked.
+func (l *Logger) RedactionStats() map[string]int64 {
+	if l.redactor == nil {
+		return map[string]int64{}
 	}
+	return l.redactor.Stats()
+}
 
-	l.zap.Log(level, msg, l.withContextFields(ctx, zapFields)...)
+// AsyncStats reports buffer and latency metrics of the asynchronous streams.
+func (l *Logger) AsyncStats() map[Stream]AsyncStats {
+	stats := make(map[Stream]AsyncStats, len(l.asyncs))
+	for stream, w := range l.asyncs {
+		stats[stream] = w.stats()
+	}
+	return stats
 }
 
 // Sync flushes any buffered log entries.
 func (l *Logger) Sync() error {
+	for _, s := range l.samplers {
+		s.flush()
+	}
 	_ = l.auditZap.Sync()
 	_ = l.securityZap.Sync()
+	if l.tenants != nil {
+		_ = l.tenants.sync()
+	}
 	return l.zap.Sync()
 }
 
 // Helpers
 
-func toZapFields(fields []ports.Field) []zap.Field {
+// toZapFields converts port fields, redacting secrets before they reach the encoder.
+func (l *Logger) toZapFields(fields []ports.Field) []zap.Field {
 	zapFields := make([]zap.Field, len(fields))
 	for i, f := range fields {
-		zapFields[i] = zap.Any(f.Key, f.Value)
+		zapFields[i] = zap.Any(f.Key, l.redactor.Redact(f.Key, f.Value))
 	}
 	return zapFields
 }
@@ -148,6 +255,15 @@ func (l *Logger) withContextFields(ctx context.Context, fields []zap.Field) []za
 	return fields
 }
 
+// ErrorLevel returns the level ErrorErr logs err at: the most specific
+// AppError code anywhere in the chain decides, anything else is an error.
+func ErrorLevel(err error) zapcore.Level {
+	if appErr := specificAppError(err); appErr != nil {
+		return mapLevel(appErr.Code)
+	}
+	return zapcore.ErrorLevel
+}
+
 func mapLevel(code types.ErrorCode) zapcore.Level {
 	switch code {
 	case types.ErrCodeInvalidInput, types.ErrCodeNotFound:
@@ -0,0 +1,289 @@
+// Package logtest provides an in-memory ports.Logger for tests, so consumers
+// don't have to hand-roll mocks of the logging port.
+//
+//	rec := logtest.New(logtest.FailOnError(t))
+//	svc := NewService(rec)
+//	svc.Run(ctx)
+//	rec.AssertLogged(t, "scan_completed", logtest.Field("scan_id", "s-1"))
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SecDuckOps/shared/logger"
+	"github.com/SecDuckOps/shared/ports"
+	"go.uber.org/zap/zapcore"
+)
+
+// Level is the severity an entry was recorded at.
+type Level string
+
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Entry is one recorded call.
+type Entry struct {
+	Level  Level
+	Stream logger.Stream
+	Event  string
+	// Message is empty for audit and security entries.
+	Message string
+	// Err is the error passed to ErrorErr.
+	Err    error
+	Fields []ports.Field
+	// Context is the context of the call; CorrelationID is extracted from it.
+	Context       context.Context
+	CorrelationID string
+	Audit         *ports.AuditEvent
+	Security      *ports.SecurityEvent
+}
+
+// Value looks up a field by key. Besides the explicit fields it resolves the
+// keys the real logger writes for structured events: actor, action,
+// resource and outcome for audit entries; ip, reason, severity, category,
+// technique and rule_id for security entries.
+func (e Entry) Value(key string) (interface{}, bool) {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	if a := e.Audit; a != nil {
+		switch key {
+		case "actor":
+			return a.Actor.ID, true
+		case "action":
+			return string(a.Action), true
+		case "resource":
+			return a.Resource.ID, true
+		case "outcome":
+			return string(a.Outcome), true
+		case "reason":
+			return a.Reason, true
+		}
+	}
+	if s := e.Security; s != nil {
+		switch key {
+		case "ip":
+			return s.Source.IP, true
+		case "reason":
+			return s.Reason, true
+		case "severity":
+			return string(s.Severity), true
+		case "category":
+			return string(s.Category), true
+		case "technique":
+			return s.Technique, true
+		case "rule_id":
+			return s.RuleID, true
+		}
+	}
+	if key == "correlation_id" && e.CorrelationID != "" {
+		return e.CorrelationID, true
+	}
+	return nil, false
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s event=%s", e.Stream, e.Level, e.Event)
+	if e.Message != "" {
+		fmt.Fprintf(&b, " msg=%q", e.Message)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, " err=%q", e.Err.Error())
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// FailOnError reports every error-level entry as a test error on t, except
+// for the allowed events.
+func FailOnError(t testing.TB, allowed ...string) Option {
+	return func(r *Recorder) {
+		r.t = t
+		for _, event := range allowed {
+			r.allowed[event] = true
+		}
+	}
+}
+
+// Recorder is a thread-safe ports.Logger that records every call.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+	t       testing.TB
+	allowed map[string]bool
+}
+
+var _ ports.Logger = (*Recorder)(nil)
+
+// New creates an empty Recorder.
+func New(opts ...Option) *Recorder {
+	r := &Recorder{allowed: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// AllowErrors stops FailOnError from reporting the given events.
+func (r *Recorder) AllowErrors(events ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		r.allowed[event] = true
+	}
+}
+
+// Debug records a debug entry.
+func (r *Recorder) Debug(ctx context.Context, event string, msg string, fields ...ports.Field) {
+	r.record(ctx, Entry{Level: LevelDebug, Stream: logger.StreamApp, Event: event, Message: msg, Fields: fields})
+}
+
+// Info records an info entry.
+func (r *Recorder) Info(ctx context.Context, event string, msg string, fields ...ports.Field) {
+	r.record(ctx, Entry{Level: LevelInfo, Stream: logger.StreamApp, Event: event, Message: msg, Fields: fields})
+}
+
+// ErrorErr records an error entry at the level the real logger would use.
+func (r *Recorder) ErrorErr(ctx context.Context, event string, err error, msg string, fields ...ports.Field) {
+	level, stream := LevelError, logger.StreamError
+	if logger.ErrorLevel(err) < zapcore.ErrorLevel {
+		level, stream = LevelWarn, logger.StreamApp
+	}
+	r.record(ctx, Entry{Level: level, Stream: stream, Event: event, Message: msg, Err: err, Fields: fields})
+}
+
+// LogAuditEvent records an audit entry.
+func (r *Recorder) LogAuditEvent(ctx context.Context, event ports.AuditEvent) {
+	r.record(ctx, Entry{Level: LevelInfo, Stream: logger.StreamAudit, Event: event.Event, Fields: event.Fields, Audit: &event})
+}
+
+// LogAudit records an audit entry through the deprecated positional API.
+func (r *Recorder) LogAudit(ctx context.Context, event string, actor string, action string, resource string, fields ...ports.Field) {
+	r.LogAuditEvent(ctx, ports.AuditEvent{
+		Event:    event,
+		Actor:    ports.AuditActor{ID: actor},
+		Action:   ports.AuditAction(action),
+		Resource: ports.AuditResource{ID: resource},
+		Fields:   fields,
+	})
+}
+
+// LogSecurityEvent records a security entry.
+func (r *Recorder) LogSecurityEvent(ctx context.Context, event ports.SecurityEvent) {
+	r.record(ctx, Entry{Level: LevelWarn, Stream: logger.StreamSecurity, Event: event.Event, Fields: event.Fields, Security: &event})
+}
+
+// LogSecurity records a security entry through the deprecated positional API.
+func (r *Recorder) LogSecurity(ctx context.Context, event string, ip string, reason string, fields ...ports.Field) {
+	r.LogSecurityEvent(ctx, ports.SecurityEvent{
+		Event:    event,
+		Severity: ports.SeverityMedium,
+		Source:   ports.SecurityEndpoint{IP: ip},
+		Reason:   reason,
+		Fields:   fields,
+	})
+}
+
+func (r *Recorder) record(ctx context.Context, e Entry) {
+	e.Context = ctx
+	if ctx != nil {
+		e.CorrelationID, _ = ctx.Value("correlation_id").(string)
+	}
+	e.Fields = append([]ports.Field(nil), e.Fields...)
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	report := r.t != nil && e.Level == LevelError && !r.allowed[e.Event]
+	r.mu.Unlock()
+
+	if report {
+		r.t.Helper()
+		r.t.Errorf("unexpected error log: %s", e)
+	}
+}
+
+// Entries returns a copy of everything recorded so far.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Find returns the entries of event that satisfy every matcher.
+func (r *Recorder) Find(event string, matchers ...Matcher) []Entry {
+	var out []Entry
+	for _, e := range r.Entries() {
+		if e.Event == event && matchAll(e, matchers) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Reset discards the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// AssertLogged fails t unless an entry of event satisfies every matcher.
+func (r *Recorder) AssertLogged(t testing.TB, event string, matchers ...Matcher) {
+	t.Helper()
+	if len(r.Find(event, matchers...)) > 0 {
+		return
+	}
+
+	var want []string
+	for _, m := range matchers {
+		want = append(want, m.desc)
+	}
+	t.Errorf("no %q entry matching [%s]; recorded:%s", event, strings.Join(want, ", "), r.dump())
+}
+
+// AssertNotLogged fails t if an entry of event satisfies every matcher.
+func (r *Recorder) AssertNotLogged(t testing.TB, event string, matchers ...Matcher) {
+	t.Helper()
+	if found := r.Find(event, matchers...); len(found) > 0 {
+		t.Errorf("unexpected %q entry: %s", event, found[0])
+	}
+}
+
+// AssertNoErrors fails t if anything was recorded at error level.
+func (r *Recorder) AssertNoErrors(t testing.TB) {
+	t.Helper()
+	for _, e := range r.Entries() {
+		if e.Level == LevelError {
+			t.Errorf("unexpected error log: %s", e)
+		}
+	}
+}
+
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return " (none)"
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString("\n\t")
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package logtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SecDuckOps/shared/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// fakeT captures failures instead of failing the real test.
+type fakeT struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_AssertLogged(t *testing.T) {
+	rec := New()
+	ctx := context.WithValue(context.Background(), "correlation_id", "c-1")
+	rec.Info(ctx, "scan_completed", "done", ports.Field{Key: "scan_id", Value: "s-1"})
+	rec.LogAuditEvent(ctx, ports.AuditEvent{Event: "resource_deleted", Actor: ports.AuditActor{ID: "alice"}, Action: ports.ActionDelete})
+	rec.LogSecurity(ctx, "auth_failed", "10.0.0.1", "bad password")
+
+	rec.AssertLogged(t, "scan_completed", Field("scan_id", "s-1"), AtLevel(LevelInfo), CorrelationID("c-1"), MessageContains("do"))
+	rec.AssertLogged(t, "resource_deleted", Field("actor", "alice"), Field("action", "delete"))
+	rec.AssertLogged(t, "auth_failed", Field("ip", "10.0.0.1"), AtLevel(LevelWarn))
+	rec.AssertNotLogged(t, "scan_completed", Field("scan_id", "s-2"))
+
+	ft := &fakeT{}
+	rec.AssertLogged(ft, "scan_completed", Field("scan_id", "s-2"))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "scan_id=s-2") || !strings.Contains(ft.errors[0], "scan_id=s-1") {
+		t.Errorf("expected a failure listing the recorded entries, got %v", ft.errors)
+	}
+}
+
+func TestRecorder_ErrorLevels(t *testing.T) {
+	rec := New()
+	ctx := context.Background()
+	rec.ErrorErr(ctx, "scan_lookup", types.New(types.ErrCodeNotFound, "missing"), "not found")
+	rec.AssertNoErrors(t)
+
+	rec.ErrorErr(ctx, "operation_failed", errors.New("boom"), "failed")
+	ft := &fakeT{}
+	rec.AssertNoErrors(ft)
+	if len(ft.errors) != 1 {
+		t.Errorf("expected one error to be reported, got %v", ft.errors)
+	}
+}
+
+func TestRecorder_FailOnError(t *testing.T) {
+	ft := &fakeT{}
+	rec := New(FailOnError(ft, "expected_failure"))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.Info(ctx, "tick", "tick")
+			rec.ErrorErr(ctx, "expected_failure", errors.New("ok"), "allowed")
+		}()
+	}
+	wg.Wait()
+	if len(ft.errors) != 0 || len(rec.Entries()) != 20 {
+		t.Fatalf("unexpected state: %v, %d entries", ft.errors, len(rec.Entries()))
+	}
+
+	rec.ErrorErr(ctx, "operation_failed", errors.New("boom"), "failed")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "operation_failed") {
+		t.Errorf("expected the unexpected error to fail the test, got %v", ft.errors)
+	}
+}
@@ -0,0 +1,84 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Matcher is a condition on a recorded entry.
+type Matcher struct {
+	desc  string
+	match func(Entry) bool
+}
+
+// Match reports whether e satisfies the matcher.
+func (m Matcher) Match(e Entry) bool { return m.match(e) }
+
+func (m Matcher) String() string { return m.desc }
+
+// Field matches entries whose field key equals value.
+func Field(key string, value interface{}) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("%s=%v", key, value),
+		match: func(e Entry) bool {
+			got, ok := e.Value(key)
+			return ok && reflect.DeepEqual(got, value)
+		},
+	}
+}
+
+// HasField matches entries that carry key, whatever its value.
+func HasField(key string) Matcher {
+	return Matcher{
+		desc: "has " + key,
+		match: func(e Entry) bool {
+			_, ok := e.Value(key)
+			return ok
+		},
+	}
+}
+
+// FieldFunc matches entries whose field key satisfies fn.
+func FieldFunc(key string, fn func(value interface{}) bool) Matcher {
+	return Matcher{
+		desc: key + " matches func",
+		match: func(e Entry) bool {
+			got, ok := e.Value(key)
+			return ok && fn(got)
+		},
+	}
+}
+
+// AtLevel matches entries recorded at level.
+func AtLevel(level Level) Matcher {
+	return Matcher{
+		desc:  "level=" + string(level),
+		match: func(e Entry) bool { return e.Level == level },
+	}
+}
+
+// MessageContains matches entries whose message contains substr.
+func MessageContains(substr string) Matcher {
+	return Matcher{
+		desc:  fmt.Sprintf("msg contains %q", substr),
+		match: func(e Entry) bool { return strings.Contains(e.Message, substr) },
+	}
+}
+
+// CorrelationID matches entries logged with the given correlation ID.
+func CorrelationID(id string) Matcher {
+	return Matcher{
+		desc:  "correlation_id=" + id,
+		match: func(e Entry) bool { return e.CorrelationID == id },
+	}
+}
+
+func matchAll(e Entry, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m.match(e) {
+			return false
+		}
+	}
+	return true
+}
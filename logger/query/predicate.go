@@ -0,0 +1,80 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Op is the comparison of a Predicate.
+type Op string
+
+const (
+	OpEqual    Op = "="
+	OpNotEqual Op = "!="
+	OpContains Op = "~"
+	OpGreater  Op = ">"
+	OpLess     Op = "<"
+)
+
+// Predicate compares the field at a dotted path with a value. Fields are
+// compared as text, except for > and < which compare numerically when both
+// sides are numbers.
+type Predicate struct {
+	Path  string
+	Op    Op
+	Value string
+}
+
+// ParsePredicate parses expressions such as "actor.kind=agent",
+// "outcome!=success", "reason~token" or "chain_seq>100".
+func ParsePredicate(expr string) (Predicate, error) {
+	// The leftmost operator splits the expression, so values may contain
+	// operator characters; "!=" wins over the "=" inside it.
+	best, bestOp := -1, Op("")
+	for _, op := range []Op{OpNotEqual, OpEqual, OpContains, OpGreater, OpLess} {
+		if i := strings.Index(expr, string(op)); i > 0 && (best < 0 || i < best) {
+			best, bestOp = i, op
+		}
+	}
+	if best > 0 {
+		return Predicate{Path: strings.TrimSpace(expr[:best]), Op: bestOp, Value: strings.TrimSpace(expr[best+len(bestOp):])}, nil
+	}
+	return Predicate{}, fmt.Errorf("invalid predicate %q: expected path followed by =, !=, ~, > or <", expr)
+}
+
+// Match reports whether the entry satisfies the predicate. A missing field
+// only satisfies !=.
+func (p Predicate) Match(e Entry) bool {
+	got, ok := e.String(p.Path)
+	if !ok {
+		return p.Op == OpNotEqual
+	}
+
+	switch p.Op {
+	case OpEqual:
+		return got == p.Value
+	case OpNotEqual:
+		return got != p.Value
+	case OpContains:
+		return strings.Contains(strings.ToLower(got), strings.ToLower(p.Value))
+	case OpGreater, OpLess:
+		a, aerr := strconv.ParseFloat(got, 64)
+		b, berr := strconv.ParseFloat(p.Value, 64)
+		cmp := strings.Compare(got, p.Value)
+		if aerr == nil && berr == nil {
+			cmp = 0
+			if a > b {
+				cmp = 1
+			} else if a < b {
+				cmp = -1
+			}
+		}
+		if p.Op == OpGreater {
+			return cmp > 0
+		}
+		return cmp < 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,226 @@
+// Package query reads the current and rotated files of a log stream in time
+// order and filters their entries, e.g. to investigate an incident or to
+// page through audit history.
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/logger"
+)
+
+// timestampLayout mirrors zapcore.ISO8601TimeEncoder used by the logger.
+const timestampLayout = "2006-01-02T15:04:05.000Z0700"
+
+// Entry is one decoded log line.
+type Entry map[string]interface{}
+
+// Time returns the timestamp of the entry.
+func (e Entry) Time() (time.Time, bool) {
+	s, ok := e["timestamp"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(timestampLayout, s); err == nil {
+		return t, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// Lookup resolves a dotted path such as "actor.id" into nested objects.
+func (e Entry) Lookup(path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(e)
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// String resolves path and renders scalars as text; objects and arrays are
+// rendered as JSON.
+func (e Entry) String(path string) (string, bool) {
+	v, ok := e.Lookup(path)
+	if !ok {
+		return "", false
+	}
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case nil:
+		return "", true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		return string(data), true
+	default:
+		return fmt.Sprint(val), true
+	}
+}
+
+// Query selects entries. Zero-valued criteria match everything.
+type Query struct {
+	Since time.Time
+	Until time.Time
+	// Events matches any of the listed event names.
+	Events []string
+	// Actor matches audit actors (actor.id, or the legacy string actor) and
+	// the source user of security events.
+	Actor         string
+	CorrelationID string
+	Where         []Predicate
+	// Limit stops the scan after this many matches.
+	Limit int
+}
+
+// Match reports whether an entry satisfies every criterion of the query.
+func (q Query) Match(e Entry) bool {
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		t, ok := e.Time()
+		if !ok || (!q.Since.IsZero() && t.Before(q.Since)) || (!q.Until.IsZero() && t.After(q.Until)) {
+			return false
+		}
+	}
+	if len(q.Events) > 0 {
+		event, _ := e.String("event")
+		found := false
+		for _, want := range q.Events {
+			if event == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Actor != "" && !matchActor(e, q.Actor) {
+		return false
+	}
+	if q.CorrelationID != "" {
+		if cid, _ := e.String("correlation_id"); cid != q.CorrelationID {
+			return false
+		}
+	}
+	for _, p := range q.Where {
+		if !p.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchActor(e Entry, actor string) bool {
+	for _, path := range []string{"actor.id", "actor", "source.user"} {
+		if v, ok := e.Lookup(path); ok {
+			if s, ok := v.(string); ok && s == actor {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Result is a matching entry together with its origin.
+type Result struct {
+	File  string
+	Line  int
+	Raw   []byte
+	Entry Entry
+}
+
+// Stats summarizes a scan.
+type Stats struct {
+	Files   int
+	Scanned int
+	Matched int
+	// Malformed counts lines that are not JSON objects; they are skipped.
+	Malformed int
+}
+
+// Scan streams the entries of a stream file (e.g. "audit.log") in logDir that
+// match q to fn, oldest first across rotated, gzipped and active files.
+// Files that lie entirely outside the time range are not opened. Raw is only
+// valid during the call to fn.
+func Scan(ctx context.Context, logDir, filename string, q Query, fn func(Result) error) (Stats, error) {
+	var stats Stats
+	files, err := logger.StreamFiles(logDir, filename)
+	if err != nil {
+		return stats, err
+	}
+
+	// A backup holds entries written up to its rotation time, so the file
+	// following a backup rotated at T only holds entries from T on.
+	var prevRotation time.Time
+	for _, path := range files {
+		rotation, isBackup := logger.BackupTime(filename, path)
+		skip := (isBackup && !q.Since.IsZero() && rotation.Before(q.Since)) ||
+			(!prevRotation.IsZero() && !q.Until.IsZero() && prevRotation.After(q.Until))
+		if isBackup {
+			prevRotation = rotation
+		}
+		if skip {
+			continue
+		}
+
+		stats.Files++
+		done, err := scanFile(ctx, path, q, &stats, fn)
+		if err != nil || done {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func scanFile(ctx context.Context, path string, q Query, stats *Stats, fn func(Result) error) (bool, error) {
+	rc, err := logger.OpenStreamFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	for lineNo := 1; ; lineNo++ {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+
+		line, readErr := r.ReadBytes('\n')
+		if raw := bytes.TrimRight(line, "\r\n"); len(raw) > 0 {
+			stats.Scanned++
+			var entry Entry
+			if err := json.Unmarshal(raw, &entry); err != nil || entry == nil {
+				stats.Malformed++
+			} else if q.Match(entry) {
+				stats.Matched++
+				if err := fn(Result{File: path, Line: lineNo, Raw: raw, Entry: entry}); err != nil {
+					return true, err
+				}
+				if q.Limit > 0 && stats.Matched >= q.Limit {
+					return true, nil
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return false, nil
+		}
+		if readErr != nil {
+			return false, fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeStream lays out audit.log with one gzipped and one plain backup.
+// Each file holds two entries one minute apart, starting at base.
+func writeStream(t *testing.T, base time.Time) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	entry := func(i int) string {
+		ts := base.Add(time.Duration(i) * time.Minute).Format(timestampLayout)
+		actor := "alice"
+		if i%2 == 1 {
+			actor = "bob"
+		}
+		return fmt.Sprintf(`{"timestamp":%q,"event":"resource_%d","actor":{"id":%q,"kind":"user"},"correlation_id":"c-%d","chain_seq":%d}`+"\n", ts, i, actor, i, i+1)
+	}
+	rotation := func(i int) string {
+		return base.Add(time.Duration(i)*time.Minute + 30*time.Second).UTC().Format("2006-01-02T15-04-05.000") // lumberjack backup layout
+	}
+
+	gzPath := filepath.Join(dir, "audit-"+rotation(1)+".log.gz")
+	f, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(entry(0) + entry(1)))
+	gz.Close()
+	f.Close()
+
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("audit-"+rotation(3)+".log", entry(2)+"not json\n"+entry(3))
+	write("audit.log", entry(4)+entry(5))
+	write("security.log", entry(6))
+	return dir
+}
+
+func collect(t *testing.T, dir string, q Query) ([]string, Stats) {
+	t.Helper()
+	var events []string
+	stats, err := Scan(context.Background(), dir, "audit.log", q, func(r Result) error {
+		events = append(events, r.Entry["event"].(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events, stats
+}
+
+func TestScan_ReadsRotatedFilesInOrder(t *testing.T) {
+	dir := writeStream(t, time.Now().Add(-time.Hour))
+	events, stats := collect(t, dir, Query{})
+
+	if got := strings.Join(events, ","); got != "resource_0,resource_1,resource_2,resource_3,resource_4,resource_5" {
+		t.Errorf("unexpected order %s", got)
+	}
+	if stats.Files != 3 || stats.Malformed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestScan_TimeRangeSkipsFiles(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	dir := writeStream(t, base)
+
+	events, stats := collect(t, dir, Query{Since: base.Add(2 * time.Minute), Until: base.Add(3 * time.Minute)})
+	if got := strings.Join(events, ","); got != "resource_2,resource_3" {
+		t.Errorf("unexpected matches %s", got)
+	}
+	if stats.Files != 1 {
+		t.Errorf("expected only the middle file to be opened, got %+v", stats)
+	}
+}
+
+func TestScan_Filters(t *testing.T) {
+	dir := writeStream(t, time.Now().Add(-time.Hour))
+	mustParse := func(expr string) Predicate {
+		p, err := ParsePredicate(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	for _, tc := range []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{"actor", Query{Actor: "bob"}, "resource_1,resource_3,resource_5"},
+		{"events", Query{Events: []string{"resource_0", "resource_4"}}, "resource_0,resource_4"},
+		{"correlation", Query{CorrelationID: "c-2"}, "resource_2"},
+		{"numeric predicate", Query{Where: []Predicate{mustParse("chain_seq>4")}}, "resource_4,resource_5"},
+		{"nested predicate", Query{Where: []Predicate{mustParse("actor.id!=alice"), mustParse("event~SOURCE_5")}}, "resource_5"},
+		{"limit", Query{Actor: "alice", Limit: 2}, "resource_0,resource_2"},
+	} {
+		events, _ := collect(t, dir, tc.q)
+		if got := strings.Join(events, ","); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParsePredicate_RejectsMissingOperator(t *testing.T) {
+	if _, err := ParsePredicate("actor.id"); err == nil {
+		t.Error("expected an error")
+	}
+	if p, err := ParsePredicate("reason!=a=b"); err != nil || p.Op != OpNotEqual || p.Path != "reason" || p.Value != "a=b" {
+		t.Errorf("unexpected predicate %+v %v", p, err)
+	}
+}
@@ -0,0 +1,461 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MaskStyle controls how a redacted value is rendered.
+type MaskStyle int
+
+const (
+	// MaskFull replaces the value with "[REDACTED]".
+	MaskFull MaskStyle = iota
+	// MaskPartial keeps the last four characters of long values: "[REDACTED]…abcd".
+	MaskPartial
+	// MaskHash replaces the value with a short SHA-256 digest so equal secrets
+	// can be correlated across entries without being disclosed.
+	MaskHash
+)
+
+const (
+	redactedText      = "[REDACTED]"
+	maxRedactionDepth = 8
+
+	// ruleKey counts values masked because of their field key.
+	ruleKey = "key"
+	// ruleEntropy counts values masked by the high-entropy detector.
+	ruleEntropy = "high_entropy"
+)
+
+// defaultSensitiveKeys are matched against normalized field keys (lowercase,
+// without '-' and '_'). A key matches when it equals or ends with an entry,
+// so "x-api-key" and "github_token" match while "max_tokens" does not.
+var defaultSensitiveKeys = []string{
+	"authorization",
+	"proxyauthorization",
+	"apikey",
+	"password",
+	"passwd",
+	"passphrase",
+	"secret",
+	"token",
+	"cookie",
+	"privatekey",
+	"credentials",
+}
+
+// Detector finds secrets inside string values regardless of their key.
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var defaultDetectors = []Detector{
+	{Name: "pem_private_key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)},
+	{Name: "aws_access_key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{Name: "github_token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "llm_api_key", Pattern: regexp.MustCompile(`\bsk-(?:ant-|or-|proj-)?[A-Za-z0-9_-]{20,}`)},
+	{Name: "bearer", Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{8,}=*`)},
+}
+
+// RedactionConfig customizes a Redactor. The zero value gives the defaults.
+type RedactionConfig struct {
+	// Keys adds sensitive key names on top of the defaults.
+	Keys []string
+	// Detectors adds value detectors on top of the defaults.
+	Detectors []Detector
+	Style     MaskStyle
+
+	// EntropyThreshold is the Shannon entropy (bits per char) above which a
+	// mixed-case alphanumeric token is treated as a secret. Default 4.0;
+	// negative disables the detector.
+	EntropyThreshold float64
+	// MinEntropyLength is the shortest token checked for entropy. Default 20.
+	MinEntropyLength int
+}
+
+// Redactor scrubs secrets from log fields before they reach the encoder.
+// It is safe for concurrent use.
+type Redactor struct {
+	keys             []string
+	detectors        []Detector
+	style            MaskStyle
+	entropyThreshold float64
+	minEntropyLength int
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewRedactor creates a Redactor with the default rules extended by cfg.
+func NewRedactor(cfg RedactionConfig) *Redactor {
+	r := &Redactor{
+		style:            cfg.Style,
+		entropyThreshold: cfg.EntropyThreshold,
+		minEntropyLength: cfg.MinEntropyLength,
+		counts:           make(map[string]int64),
+	}
+	if r.entropyThreshold == 0 {
+		r.entropyThreshold = 4.0
+	}
+	if r.minEntropyLength == 0 {
+		r.minEntropyLength = 20
+	}
+
+	r.keys = append(r.keys, defaultSensitiveKeys...)
+	for _, k := range cfg.Keys {
+		r.keys = append(r.keys, normalizeKey(k))
+	}
+	r.detectors = append(r.detectors, defaultDetectors...)
+	r.detectors = append(r.detectors, cfg.Detectors...)
+	return r
+}
+
+// Stats returns how many values each rule has redacted so far.
+func (r *Redactor) Stats() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]int64, len(r.counts))
+	for k, v := range r.counts {
+		stats[k] = v
+	}
+	return stats
+}
+
+// Redact returns value with secrets masked. Maps, slices and structs are
+// walked recursively and copied only when something inside them was masked.
+// Marshalers, Stringers and byte slices are scanned in their rendered form and
+// replaced by it when it held a secret.
+func (r *Redactor) Redact(key string, value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+	out, _ := r.redact(key, value, 0)
+	return out
+}
+
+// RedactString masks secrets found inside s by the value detectors.
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	out, _ := r.redactString(s)
+	return out
+}
+
+// IsSensitiveKey reports whether values stored under key are always masked.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	k := normalizeKey(key)
+	if k == "" {
+		return false
+	}
+	for _, s := range r.keys {
+		if strings.HasSuffix(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) redact(key string, value interface{}, depth int) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if key != "" && r.IsSensitiveKey(key) {
+		r.count(ruleKey)
+		return r.mask(fmt.Sprint(value)), true
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value, false
+	case error:
+		msg, changed := r.redactString(v.Error())
+		if changed {
+			return msg, true
+		}
+		return value, false
+	case json.RawMessage:
+		return r.redactJSON(value, v, depth)
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return value, false
+		}
+		return r.redactJSON(value, data, depth)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return value, false
+		}
+		return r.redactRendered(value, string(text))
+	case fmt.Stringer:
+		return r.redactRendered(value, v.String())
+	case []byte:
+		// zap base64-encodes bytes, which hides nothing.
+		return r.redactRendered(value, string(v))
+	}
+
+	if depth >= maxRedactionDepth {
+		return value, false
+	}
+	return r.redactReflect(reflect.ValueOf(value), depth)
+}
+
+func (r *Redactor) redactReflect(rv reflect.Value, depth int) (interface{}, bool) {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return rv.Interface(), false
+		}
+		out, changed := r.redact("", rv.Elem().Interface(), depth+1)
+		if !changed {
+			return rv.Interface(), false
+		}
+		return out, true
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return rv.Interface(), false
+		}
+		copied := make(map[string]interface{}, rv.Len())
+		changed := false
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			v, c := r.redact(k, iter.Value().Interface(), depth+1)
+			copied[k] = v
+			changed = changed || c
+		}
+		if !changed {
+			return rv.Interface(), false
+		}
+		return copied, true
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return r.redactRendered(rv.Interface(), string(rv.Bytes()))
+		}
+		copied := make([]interface{}, rv.Len())
+		changed := false
+		for i := 0; i < rv.Len(); i++ {
+			v, c := r.redact("", rv.Index(i).Interface(), depth+1)
+			copied[i] = v
+			changed = changed || c
+		}
+		if !changed {
+			return rv.Interface(), false
+		}
+		return copied, true
+
+	case reflect.Struct:
+		rt := rv.Type()
+		copied := make(map[string]interface{}, rt.NumField())
+		changed := false
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, omitEmpty, skip := jsonFieldName(sf)
+			if skip {
+				continue
+			}
+			fv := rv.Field(i)
+			if omitEmpty && fv.IsZero() {
+				continue
+			}
+			v, c := r.redact(name, fv.Interface(), depth+1)
+			copied[name] = v
+			changed = changed || c
+		}
+		if !changed {
+			return rv.Interface(), false
+		}
+		return copied, true
+	}
+
+	return rv.Interface(), false
+}
+
+// redactRendered scans the text form of value, returning the masked text in
+// place of value when it held a secret.
+func (r *Redactor) redactRendered(value interface{}, text string) (interface{}, bool) {
+	if out, changed := r.redactString(text); changed {
+		return out, true
+	}
+	return value, false
+}
+
+// redactJSON decodes the JSON form of value so keys and strings inside it are
+// redacted like any other structure. Invalid JSON is scanned as text.
+func (r *Redactor) redactJSON(value interface{}, data []byte, depth int) (interface{}, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return r.redactRendered(value, string(data))
+	}
+	if depth >= maxRedactionDepth {
+		return r.redactRendered(value, string(data))
+	}
+	if out, changed := r.redact("", decoded, depth+1); changed {
+		return out, true
+	}
+	return value, false
+}
+
+func (r *Redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, d := range r.detectors {
+		if !d.Pattern.MatchString(s) {
+			continue
+		}
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			r.count(d.Name)
+			return r.mask(match)
+		})
+		changed = true
+	}
+
+	if r.entropyThreshold > 0 && len(s) >= r.minEntropyLength {
+		if out, c := r.redactHighEntropy(s); c {
+			s = out
+			changed = true
+		}
+	}
+	return s, changed
+}
+
+// redactHighEntropy masks tokens that look like random key material. Only
+// mixed-case alphanumeric tokens qualify, which keeps hex digests and UUIDs.
+func (r *Redactor) redactHighEntropy(s string) (string, bool) {
+	isSep := func(c rune) bool {
+		return unicode.IsSpace(c) || strings.ContainsRune(`"'=,;:()[]{}<>`, c)
+	}
+
+	var b strings.Builder
+	changed := false
+	start := -1
+	flush := func(end int) {
+		tok := s[start:end]
+		if len(tok) >= r.minEntropyLength && !strings.HasPrefix(tok, redactedText) &&
+			looksRandom(tok) && shannonEntropy(tok) >= r.entropyThreshold {
+			r.count(ruleEntropy)
+			b.WriteString(r.mask(tok))
+			changed = true
+		} else {
+			b.WriteString(tok)
+		}
+	}
+	for i, c := range s {
+		if isSep(c) {
+			if start >= 0 {
+				flush(i)
+				start = -1
+			}
+			b.WriteRune(c)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		flush(len(s))
+	}
+	return b.String(), changed
+}
+
+func (r *Redactor) mask(secret string) string {
+	switch r.style {
+	case MaskPartial:
+		if len(secret) >= 16 {
+			return redactedText + "…" + secret[len(secret)-4:]
+		}
+	case MaskHash:
+		sum := sha256.Sum256([]byte(secret))
+		return "[REDACTED:sha256:" + hex.EncodeToString(sum[:6]) + "]"
+	}
+	return redactedText
+}
+
+func (r *Redactor) count(rule string) {
+	r.mu.Lock()
+	r.counts[rule]++
+	r.mu.Unlock()
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(key)
+}
+
+func jsonFieldName(sf reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name = sf.Name
+	if tag != "" {
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+	}
+	return name, omitEmpty, false
+}
+
+func looksRandom(tok string) bool {
+	if strings.HasPrefix(tok, "/") || strings.Contains(tok, "//") {
+		return false // paths and URLs
+	}
+	var upper, lower, digit bool
+	for _, c := range tok {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		case c == '+' || c == '/' || c == '-' || c == '_' || c == '.':
+		default:
+			return false
+		}
+	}
+	return upper && lower && digit
+}
+
+func shannonEntropy(s string) float64 {
+	freq := make(map[rune]int)
+	for _, c := range s {
+		freq[c]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, count := range freq {
+		p := float64(count) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
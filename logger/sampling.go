@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig throttles repeated events on a stream. Every event name is
+// sampled and rate limited independently of the others.
+type SamplingConfig struct {
+	// Interval is the sampling window. Default 1s.
+	Interval time.Duration
+	// First entries of an event are always logged within each interval.
+	First int
+	// Thereafter every Mth entry of the event is logged for the rest of the
+	// interval; 0 suppresses everything after First.
+	Thereafter int
+
+	// RatePerSecond enables a per-event token bucket refilled at this rate.
+	// 0 disables rate limiting.
+	RatePerSecond float64
+	// Burst is the bucket capacity. Default max(1, RatePerSecond).
+	Burst int
+
+	// SummaryInterval is how often "log_suppressed" summary entries are
+	// emitted for events that were dropped. Default 10s.
+	SummaryInterval time.Duration
+}
+
+// maxSampledEvents caps the events tracked per stream. Once reached, idle
+// events are expired and new events beyond the cap share one window under
+// overflowEvent.
+const (
+	maxSampledEvents = 4096
+	overflowEvent    = "*"
+)
+
+type eventWindow struct {
+	start    time.Time
+	count    int
+	lastSeen time.Time
+
+	tokens     float64
+	lastRefill time.Time
+
+	suppressed int64
+	level      zapcore.Level
+}
+
+// sampler holds the per-event state of one stream. It is shared by every
+// core derived from the stream via With. The summary goroutine only runs
+// while entries are being suppressed, and stops for good with the Logger.
+type sampler struct {
+	cfg    SamplingConfig
+	stream Stream
+	out    zapcore.Core
+	// idle is how long an event stays tracked after its last entry: past
+	// it, a fresh window behaves exactly like the expired one.
+	idle time.Duration
+
+	mu      sync.Mutex
+	events  map[string]*eventWindow
+	pending int64
+	running bool
+	stopped bool
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newSampler(stream Stream, cfg SamplingConfig, out zapcore.Core) *sampler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.RatePerSecond > 0 && cfg.Burst <= 0 {
+		cfg.Burst = int(cfg.RatePerSecond)
+		if cfg.Burst < 1 {
+			cfg.Burst = 1
+		}
+	}
+	if cfg.SummaryInterval <= 0 {
+		cfg.SummaryInterval = 10 * time.Second
+	}
+
+	idle := cfg.Interval
+	if cfg.RatePerSecond > 0 {
+		if refill := time.Duration(float64(cfg.Burst) / cfg.RatePerSecond * float64(time.Second)); refill > idle {
+			idle = refill
+		}
+	}
+
+	return &sampler{
+		cfg:    cfg,
+		stream: stream,
+		out:    out,
+		idle:   idle,
+		events: make(map[string]*eventWindow),
+		done:   make(chan struct{}),
+	}
+}
+
+// run emits the summaries until a whole interval passes without suppressed
+// entries or the sampler is stopped.
+func (s *sampler) run() {
+	ticker := time.NewTicker(s.cfg.SummaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			idle := s.pending == 0
+			if idle {
+				s.running = false
+			}
+			s.mu.Unlock()
+			if idle {
+				return
+			}
+			s.flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// stop ends the summary goroutine after emitting the pending summaries.
+func (s *sampler) stop() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.stopped = true
+		s.mu.Unlock()
+		close(s.done)
+		s.flush()
+	})
+}
+
+// expire forgets the events idle since before now-s.idle with nothing left
+// to summarize. The caller holds s.mu.
+func (s *sampler) expire(now time.Time) {
+	for event, w := range s.events {
+		if w.suppressed == 0 && now.Sub(w.lastSeen) >= s.idle {
+			delete(s.events, event)
+		}
+	}
+}
+
+// allow decides whether an entry of the given event may be written.
+func (s *sampler) allow(event string, ent zapcore.Entry) bool {
+	now := ent.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.events[event]
+	if !ok && len(s.events) >= maxSampledEvents {
+		s.expire(now)
+		if len(s.events) >= maxSampledEvents {
+			event = overflowEvent
+			w, ok = s.events[event]
+		}
+	}
+	if !ok {
+		w = &eventWindow{start: now, tokens: float64(s.cfg.Burst), lastRefill: now, level: zapcore.DebugLevel}
+		s.events[event] = w
+	}
+	w.lastSeen = now
+
+	allowed := true
+	if s.cfg.First > 0 || s.cfg.Thereafter > 0 {
+		if now.Sub(w.start) >= s.cfg.Interval {
+			w.start = now
+			w.count = 0
+		}
+		w.count++
+		if w.count > s.cfg.First {
+			allowed = s.cfg.Thereafter > 0 && (w.count-s.cfg.First)%s.cfg.Thereafter == 0
+		}
+	}
+
+	if allowed && s.cfg.RatePerSecond > 0 {
+		elapsed := now.Sub(w.lastRefill).Seconds()
+		if elapsed > 0 {
+			w.tokens += elapsed * s.cfg.RatePerSecond
+			if w.tokens > float64(s.cfg.Burst) {
+				w.tokens = float64(s.cfg.Burst)
+			}
+			w.lastRefill = now
+		}
+		if w.tokens >= 1 {
+			w.tokens--
+		} else {
+			allowed = false
+		}
+	}
+
+	if !allowed {
+		w.suppressed++
+		if ent.Level > w.level {
+			w.level = ent.Level
+		}
+		s.pending++
+		if !s.running && !s.stopped {
+			s.running = true
+			go s.run()
+		}
+	}
+	return allowed
+}
+
+// flush emits one summary entry per event that had entries suppressed since
+// the previous flush.
+func (s *sampler) flush() {
+	type summary struct {
+		event string
+		count int64
+		level zapcore.Level
+	}
+
+	s.mu.Lock()
+	var pending []summary
+	for event, w := range s.events {
+		if w.suppressed > 0 {
+			pending = append(pending, summary{event: event, count: w.suppressed, level: w.level})
+			w.suppressed = 0
+			w.level = zapcore.DebugLevel
+		}
+	}
+	s.pending = 0
+	s.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].event < pending[j].event })
+	for _, p := range pending {
+		ent := zapcore.Entry{
+			Level:   p.level,
+			Time:    time.Now(),
+			Message: "Log events suppressed",
+		}
+		_ = s.out.Write(ent, []zapcore.Field{
+			zap.String("event", "log_suppressed"),
+			zap.String("stream", string(s.stream)),
+			zap.String("suppressed_event", p.event),
+			zap.Int64("suppressed_count", p.count),
+		})
+	}
+}
+
+// samplingCore drops entries the sampler rejects before they are encoded.
+type samplingCore struct {
+	zapcore.Core
+	sampler *sampler
+}
+
+func newSamplingCore(stream Stream, cfg SamplingConfig, core zapcore.Core) (*samplingCore, *sampler) {
+	s := newSampler(stream, cfg, core)
+	return &samplingCore{Core: core, sampler: s}, s
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *samplingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.sampler.allow(eventName(fields), ent) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// eventName extracts the "event" field every Logger method attaches.
+func eventName(fields []zapcore.Field) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == "event" && fields[i].Type == zapcore.StringType {
+			return fields[i].String
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampling_SuppressesRepeatedEventsAndSummarizes(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithSampling(StreamApp, SamplingConfig{
+		Interval:        time.Hour,
+		First:           2,
+		Thereafter:      4,
+		SummaryInterval: time.Hour,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		l.Info(ctx, "operation_failed", "scanner failed")
+	}
+	l.Info(ctx, "agent_start", "agent started")
+	_ = l.Sync()
+
+	counts := map[string]int{}
+	var summary map[string]interface{}
+	for _, e := range readEntries(t, filepath.Join(dir, "app.log")) {
+		counts[e["event"].(string)]++
+		if e["event"] == "log_suppressed" {
+			summary = e
+		}
+	}
+
+	// 2 initial entries, then every 4th of the remaining 8.
+	if counts["operation_failed"] != 4 || counts["agent_start"] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if summary == nil || summary["suppressed_event"] != "operation_failed" || summary["suppressed_count"] != float64(6) {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+}
+
+func TestSampling_TokenBucket(t *testing.T) {
+	s := newSampler(StreamApp, SamplingConfig{RatePerSecond: 2, Burst: 2}, zapcore.NewNopCore())
+	t.Cleanup(s.stop)
+
+	start := time.Unix(0, 0)
+	at := func(d time.Duration) zapcore.Entry { return zapcore.Entry{Time: start.Add(d)} }
+
+	got := []bool{
+		s.allow("e", at(0)),
+		s.allow("e", at(0)),
+		s.allow("e", at(0)),
+		s.allow("e", at(500*time.Millisecond)),
+		s.allow("other", at(500*time.Millisecond)),
+	}
+	want := []bool{true, true, false, true, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	if s.events["e"].suppressed != 1 {
+		t.Errorf("expected 1 suppressed entry, got %d", s.events["e"].suppressed)
+	}
+}
+
+func TestSampling_BoundsTrackedEvents(t *testing.T) {
+	s := newSampler(StreamApp, SamplingConfig{Interval: time.Second, First: 1}, zapcore.NewNopCore())
+	t.Cleanup(s.stop)
+
+	start := time.Unix(0, 0)
+	for i := 0; i < maxSampledEvents; i++ {
+		s.allow(fmt.Sprintf("event_%d", i), zapcore.Entry{Time: start})
+	}
+	if s.running {
+		t.Error("the summary goroutine must not run before anything is suppressed")
+	}
+
+	// At the cap, new events share the overflow window...
+	if !s.allow("late", zapcore.Entry{Time: start}) || s.allow("later", zapcore.Entry{Time: start}) {
+		t.Error("expected new events to share the overflow window")
+	}
+	// ...until idle events expire. The overflow window still has a summary due.
+	s.allow("fresh", zapcore.Entry{Time: start.Add(2 * time.Second)})
+	if len(s.events) != 2 || s.events["fresh"] == nil || s.events[overflowEvent] == nil {
+		t.Errorf("expected idle events to expire, tracking %d", len(s.events))
+	}
+}
+
+func TestSampling_RejectedOnLosslessStreams(t *testing.T) {
+	for _, stream := range []Stream{StreamAudit, StreamSecurity} {
+		if _, err := New("test", "info", WithLogDir(t.TempDir()), WithSampling(stream, SamplingConfig{First: 1})); err == nil {
+			t.Errorf("expected sampling on %s to be rejected", stream)
+		}
+	}
+}
@@ -5,15 +5,93 @@ import (
 
 	"github.com/SecDuckOps/shared/ports"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// LogSecurityEvent logs a structured security event to the security log (security.log).
+func (l *Logger) LogSecurityEvent(ctx context.Context, event ports.SecurityEvent) {
+	implicit := []string{"severity", "category", "source", "reason"}
+	if !event.Destination.IsZero() {
+		implicit = append(implicit, "destination")
+	}
+	if event.Outcome != "" {
+		implicit = append(implicit, "outcome")
+	}
+	if event.Technique != "" {
+		implicit = append(implicit, "technique")
+	}
+	if event.RuleID != "" {
+		implicit = append(implicit, "rule_id")
+	}
+	l.checkEvent(event.Event, event.Fields, implicit...)
+	event.Reason = l.redactor.RedactString(event.Reason)
+
+	zapFields := l.toZapFields(event.Fields)
+	zapFields = append(zapFields, securityEventFields(event)...)
+	l.routed(ctx, StreamSecurity, l.securityZap, zapcore.WarnLevel, "Security Event", l.withContextFields(ctx, zapFields))
+}
+
 // LogSecurity logs security-related events to security.log.
+//
+// Deprecated: use LogSecurityEvent. Entries keep their original shape, with
+// the ip at the top level, so existing parsers and alerts still match; the
+// SIEM encoders read it as the source address.
 func (l *Logger) LogSecurity(ctx context.Context, event string, ip string, reason string, fields ...ports.Field) {
-	zapFields := toZapFields(fields)
+	l.checkEvent(event, fields, "ip", "reason")
+
+	zapFields := l.toZapFields(fields)
 	zapFields = append(zapFields,
 		zap.String("event", event),
 		zap.String("ip", ip),
-		zap.String("reason", reason),
+		zap.String("reason", l.redactor.RedactString(reason)),
 	)
-	l.securityZap.Warn("Security Event", l.withContextFields(ctx, zapFields)...)
+	l.routed(ctx, StreamSecurity, l.securityZap, zapcore.WarnLevel, "Security Event", l.withContextFields(ctx, zapFields))
+}
+
+// securityEventFields serializes a SecurityEvent. The keys are the contract
+// the SIEM encoders map onto CEF, LEEF and ECS attributes.
+func securityEventFields(e ports.SecurityEvent) []zap.Field {
+	severity := e.Severity
+	if severity == "" {
+		severity = ports.SeverityMedium
+	}
+
+	fields := []zap.Field{
+		zap.String("event", e.Event),
+		zap.String("severity", string(severity)),
+		zap.String("category", string(e.Category)),
+		zap.Object("source", securityEndpoint(e.Source)),
+		zap.String("reason", e.Reason),
+	}
+	if !e.Destination.IsZero() {
+		fields = append(fields, zap.Object("destination", securityEndpoint(e.Destination)))
+	}
+	if e.Outcome != "" {
+		fields = append(fields, zap.String("outcome", string(e.Outcome)))
+	}
+	if e.Technique != "" {
+		fields = append(fields, zap.String("technique", e.Technique))
+	}
+	if e.RuleID != "" {
+		fields = append(fields, zap.String("rule_id", e.RuleID))
+	}
+	return fields
+}
+
+type securityEndpoint ports.SecurityEndpoint
+
+func (e securityEndpoint) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if e.IP != "" {
+		enc.AddString("ip", e.IP)
+	}
+	if e.Port != 0 {
+		enc.AddInt("port", e.Port)
+	}
+	if e.Hostname != "" {
+		enc.AddString("hostname", e.Hostname)
+	}
+	if e.User != "" {
+		enc.AddString("user", e.User)
+	}
+	return nil
 }
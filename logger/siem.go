@@ -0,0 +1,563 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/ports"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// SinkFormat selects how entries are encoded for a sink.
+type SinkFormat int
+
+const (
+	// FormatJSON ships the same JSON lines as the local files.
+	FormatJSON SinkFormat = iota
+	// FormatCEF encodes ArcSight Common Event Format lines.
+	FormatCEF
+	// FormatLEEF encodes QRadar Log Event Extended Format 1.0 lines.
+	FormatLEEF
+	// FormatECS encodes Elastic Common Schema JSON documents.
+	FormatECS
+)
+
+// ecsVersion is the ECS release the ECS encoder maps onto.
+const ecsVersion = "8.11.0"
+
+// SIEMHeader identifies the producing device in CEF and LEEF headers.
+type SIEMHeader struct {
+	Vendor  string
+	Product string
+	Version string
+}
+
+type formattedSink struct {
+	sink   Sink
+	format SinkFormat
+}
+
+// WithSinkFormat routes the given streams to sink encoded in format. Unlike
+// WithSink, the sink gets its own encoder instead of sharing the JSON lines
+// of the files, so audit entries sent this way carry no chain links and the
+// entries are written directly rather than through a WithAsync buffer.
+// Without streams the sink receives every stream.
+func WithSinkFormat(sink Sink, format SinkFormat, streams ...Stream) Option {
+	if format == FormatJSON {
+		return WithSink(sink, streams...)
+	}
+	return func(o *options) {
+		if len(streams) == 0 {
+			streams = []Stream{StreamApp, StreamError, StreamAudit, StreamSecurity}
+		}
+		for _, s := range streams {
+			o.formatted[s] = append(o.formatted[s], formattedSink{sink: sink, format: format})
+		}
+	}
+}
+
+// WithSIEMHeader overrides the device fields of CEF and LEEF headers.
+// Empty fields keep their defaults: vendor "SecDuckOps", the service name as
+// product and version "1.0".
+func WithSIEMHeader(h SIEMHeader) Option {
+	return func(o *options) {
+		o.siemHeader = h
+	}
+}
+
+// formattedCores builds one core per formatted sink of a stream.
+func (l *Logger) formattedCores(stream Stream, o options, enab zapcore.LevelEnabler) []zapcore.Core {
+	header := o.siemHeader
+	if header.Vendor == "" {
+		header.Vendor = "SecDuckOps"
+	}
+	if header.Product == "" {
+		header.Product = l.service
+	}
+	if header.Version == "" {
+		header.Version = "1.0"
+	}
+
+	var cores []zapcore.Core
+	for _, fs := range o.formatted[stream] {
+		enc := newSIEMEncoder(fs.format, header)
+		cores = append(cores, zapcore.NewCore(enc, closeGuard{out: fs.sink, closed: &l.closed}, enab))
+	}
+	return cores
+}
+
+// siemEncoder collects the fields of an entry into a map and renders them
+// in one of the SIEM formats.
+type siemEncoder struct {
+	*zapcore.MapObjectEncoder
+	format SinkFormat
+	header SIEMHeader
+}
+
+var siemBufferPool = buffer.NewPool()
+
+func newSIEMEncoder(format SinkFormat, header SIEMHeader) *siemEncoder {
+	return &siemEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), format: format, header: header}
+}
+
+func (e *siemEncoder) Clone() zapcore.Encoder {
+	clone := newSIEMEncoder(e.format, e.header)
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *siemEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	m := e.Clone().(*siemEncoder)
+	for _, f := range fields {
+		f.AddTo(m)
+	}
+	rec := newSIEMRecord(ent, m.Fields)
+
+	buf := siemBufferPool.Get()
+	switch e.format {
+	case FormatCEF:
+		rec.writeCEF(buf, e.header)
+	case FormatLEEF:
+		rec.writeLEEF(buf, e.header)
+	case FormatECS:
+		if err := rec.writeECS(buf); err != nil {
+			buf.Free()
+			return nil, err
+		}
+	default:
+		buf.Free()
+		return nil, fmt.Errorf("unsupported sink format %d", e.format)
+	}
+	buf.AppendByte('\n')
+	return buf, nil
+}
+
+// siemRecord is an entry split into the attributes every format maps
+// explicitly and the remaining fields.
+type siemRecord struct {
+	time      time.Time
+	level     zapcore.Level
+	message   string
+	event     string
+	severity  int
+	category  string
+	outcome   string
+	reason    string
+	technique string
+	ruleID    string
+	service   string
+	source    map[string]interface{}
+	dest      map[string]interface{}
+	extra     map[string]interface{}
+}
+
+func newSIEMRecord(ent zapcore.Entry, fields map[string]interface{}) siemRecord {
+	r := siemRecord{
+		time:     ent.Time,
+		level:    ent.Level,
+		message:  ent.Message,
+		severity: levelSeverity(ent.Level),
+		extra:    make(map[string]interface{}),
+	}
+	for k, v := range fields {
+		s, isString := v.(string)
+		switch {
+		case k == "event" && isString:
+			r.event = s
+		case k == "severity" && isString:
+			r.severity = ports.SecuritySeverity(s).Score()
+		case k == "category" && isString:
+			r.category = s
+		case k == "outcome" && isString:
+			r.outcome = s
+		case k == "reason" && isString:
+			r.reason = s
+		case k == "technique" && isString:
+			r.technique = s
+		case k == "rule_id" && isString:
+			r.ruleID = s
+		case k == "service" && isString:
+			r.service = s
+		case k == "source":
+			r.source, _ = v.(map[string]interface{})
+		case k == "ip" && isString && fields["source"] == nil:
+			// LogSecurity entries carry the source address at the top level.
+			r.source = map[string]interface{}{"ip": s}
+		case k == "destination":
+			r.dest, _ = v.(map[string]interface{})
+		default:
+			r.extra[k] = v
+		}
+	}
+	return r
+}
+
+// levelSeverity scores entries that carry no explicit severity.
+func levelSeverity(lvl zapcore.Level) int {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return 1
+	case lvl == zapcore.InfoLevel:
+		return 3
+	case lvl == zapcore.WarnLevel:
+		return 5
+	case lvl == zapcore.ErrorLevel:
+		return 7
+	default:
+		return 10
+	}
+}
+
+// pair is one key=value attribute of a CEF extension or LEEF payload.
+type pair struct {
+	key   string
+	value string
+}
+
+// attributes maps the record onto format-specific keys. Attributes the
+// format has no key for are left out.
+func (r siemRecord) attributes(keys map[string]string) []pair {
+	var out []pair
+	add := func(name, value string) {
+		if value != "" && keys[name] != "" {
+			out = append(out, pair{keys[name], value})
+		}
+	}
+	endpoint := func(prefix string, ep map[string]interface{}) {
+		for _, attr := range []string{"ip", "port", "hostname", "user"} {
+			if v, ok := ep[attr]; ok {
+				add(prefix+attr, fmt.Sprint(v))
+			}
+		}
+	}
+
+	add("time", strconv.FormatInt(r.time.UnixMilli(), 10))
+	endpoint("src_", r.source)
+	endpoint("dst_", r.dest)
+	add("category", r.category)
+	add("outcome", r.outcome)
+	add("reason", r.reason)
+	add("message", r.message)
+	// CEF has no dedicated keys for these and uses labelled custom strings.
+	if label := keys["technique_label"]; label != "" && r.technique != "" {
+		out = append(out, pair{label, "mitreTechnique"})
+	}
+	add("technique", r.technique)
+	if label := keys["rule_label"]; label != "" && r.ruleID != "" {
+		out = append(out, pair{label, "ruleId"})
+	}
+	add("rule", r.ruleID)
+	add("service", r.service)
+	return out
+}
+
+// extraKeys returns the keys of the remaining fields in sorted order.
+func (r siemRecord) extraKeys() []string {
+	keys := make([]string, 0, len(r.extra))
+	for k := range r.extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cefExtraKeys maps well-known fields onto CEF dictionary keys.
+var cefExtraKeys = map[string]string{
+	"correlation_id": "externalId",
+}
+
+// cefFirstCustomString is the first deviceCustomString slot left for the
+// remaining fields; cs1 and cs2 hold the technique and the rule.
+const (
+	cefFirstCustomString = 3
+	cefLastCustomString  = 6
+)
+
+// cefExtensions maps the remaining fields onto CEF dictionary keys: known
+// fields to their own key, the others to labelled custom strings cs3..cs6.
+// When they do not fit, cs6 carries the rest as a JSON object.
+func (r siemRecord) cefExtensions() []pair {
+	var out []pair
+	var custom []string
+	for _, k := range r.extraKeys() {
+		if key, ok := cefExtraKeys[k]; ok {
+			out = append(out, pair{key, stringify(r.extra[k])})
+		} else {
+			custom = append(custom, k)
+		}
+	}
+
+	slots := cefLastCustomString - cefFirstCustomString + 1
+	for i, k := range custom {
+		n := strconv.Itoa(cefFirstCustomString + i)
+		if i == slots-1 && len(custom) > slots {
+			rest := make(map[string]interface{}, len(custom)-i)
+			for _, k := range custom[i:] {
+				rest[k] = r.extra[k]
+			}
+			out = append(out, pair{"cs" + n + "Label", "fields"}, pair{"cs" + n, stringify(rest)})
+			break
+		}
+		out = append(out, pair{"cs" + n + "Label", camelKey(k)}, pair{"cs" + n, stringify(r.extra[k])})
+	}
+	return out
+}
+
+var cefKeys = map[string]string{
+	"time":            "rt",
+	"src_ip":          "src",
+	"src_port":        "spt",
+	"src_hostname":    "shost",
+	"src_user":        "suser",
+	"dst_ip":          "dst",
+	"dst_port":        "dpt",
+	"dst_hostname":    "dhost",
+	"dst_user":        "duser",
+	"category":        "cat",
+	"outcome":         "outcome",
+	"reason":          "reason",
+	"message":         "msg",
+	"technique_label": "cs1Label",
+	"technique":       "cs1",
+	"rule_label":      "cs2Label",
+	"rule":            "cs2",
+	// The service is the header's device product.
+}
+
+// writeCEF renders
+// CEF:0|Vendor|Product|Version|SignatureID|Name|Severity|Extension.
+func (r siemRecord) writeCEF(buf *buffer.Buffer, h SIEMHeader) {
+	name := r.reason
+	if name == "" {
+		name = r.message
+	}
+	buf.AppendString("CEF:0")
+	for _, field := range []string{h.Vendor, h.Product, h.Version, r.event, name, strconv.Itoa(r.severity)} {
+		buf.AppendByte('|')
+		buf.AppendString(escapeHeader(field))
+	}
+	buf.AppendByte('|')
+
+	for i, a := range append(r.attributes(cefKeys), r.cefExtensions()...) {
+		if i > 0 {
+			buf.AppendByte(' ')
+		}
+		buf.AppendString(a.key)
+		buf.AppendByte('=')
+		buf.AppendString(escapeCEFValue(a.value))
+	}
+}
+
+var leefKeys = map[string]string{
+	"time":         "devTime",
+	"src_ip":       "src",
+	"src_port":     "srcPort",
+	"src_hostname": "srcHostName",
+	"src_user":     "usrName",
+	"dst_ip":       "dst",
+	"dst_port":     "dstPort",
+	"dst_hostname": "dstHostName",
+	"dst_user":     "dstUsrName",
+	"category":     "cat",
+	"outcome":      "outcome",
+	"reason":       "reason",
+	"message":      "msg",
+	"technique":    "technique",
+	"rule":         "ruleId",
+	"service":      "service",
+}
+
+// writeLEEF renders LEEF:1.0|Vendor|Product|Version|EventID| followed by
+// tab-delimited attributes.
+func (r siemRecord) writeLEEF(buf *buffer.Buffer, h SIEMHeader) {
+	buf.AppendString("LEEF:1.0")
+	for _, field := range []string{h.Vendor, h.Product, h.Version, r.event} {
+		buf.AppendByte('|')
+		buf.AppendString(escapeHeader(field))
+	}
+	buf.AppendByte('|')
+
+	// LEEF severities range from 1 to 10.
+	attrs := append([]pair{{"sev", strconv.Itoa(max(r.severity, 1))}}, r.attributes(leefKeys)...)
+	for _, k := range r.extraKeys() {
+		attrs = append(attrs, pair{camelKey(k), stringify(r.extra[k])})
+	}
+	for i, a := range attrs {
+		if i > 0 {
+			buf.AppendByte('\t')
+		}
+		buf.AppendString(a.key)
+		buf.AppendByte('=')
+		buf.AppendString(escapeLEEFValue(a.value))
+	}
+}
+
+// writeECS renders the record as an Elastic Common Schema document.
+func (r siemRecord) writeECS(buf *buffer.Buffer) error {
+	doc := map[string]interface{}{
+		"@timestamp": r.time.UTC().Format(time.RFC3339Nano),
+		"message":    r.message,
+		"ecs":        map[string]interface{}{"version": ecsVersion},
+		"log":        map[string]interface{}{"level": r.level.String()},
+	}
+
+	kind := "event"
+	if r.ruleID != "" {
+		kind = "alert"
+	}
+	event := map[string]interface{}{"kind": kind, "severity": r.severity}
+	if r.event != "" {
+		event["action"] = r.event
+	}
+	if r.category != "" {
+		event["category"] = []string{r.category}
+	}
+	if r.reason != "" {
+		event["reason"] = r.reason
+	}
+	switch r.outcome {
+	case "":
+	case string(ports.OutcomeSuccess):
+		event["outcome"] = "success"
+	case string(ports.OutcomeFailure), string(ports.OutcomeDenied):
+		event["outcome"] = "failure"
+	default:
+		event["outcome"] = "unknown"
+	}
+	doc["event"] = event
+
+	if r.service != "" {
+		doc["service"] = map[string]interface{}{"name": r.service}
+	}
+	if ep := ecsEndpoint(r.source); ep != nil {
+		doc["source"] = ep
+	}
+	if ep := ecsEndpoint(r.dest); ep != nil {
+		doc["destination"] = ep
+	}
+	if r.technique != "" {
+		doc["threat"] = map[string]interface{}{
+			"framework": "MITRE ATT&CK",
+			"technique": map[string]interface{}{"id": []string{r.technique}},
+		}
+	}
+	if r.ruleID != "" {
+		doc["rule"] = map[string]interface{}{"id": r.ruleID}
+	}
+	if id, ok := r.extra["correlation_id"].(string); ok {
+		doc["trace"] = map[string]interface{}{"id": id}
+		delete(r.extra, "correlation_id")
+	}
+	if len(r.extra) > 0 {
+		// ECS labels are flat keyword values.
+		labels := make(map[string]string, len(r.extra))
+		for k, v := range r.extra {
+			labels[k] = stringify(v)
+		}
+		doc["labels"] = labels
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	buf.AppendString(string(data))
+	return nil
+}
+
+func ecsEndpoint(ep map[string]interface{}) map[string]interface{} {
+	if len(ep) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{})
+	if v, ok := ep["ip"]; ok {
+		out["ip"] = v
+	}
+	if v, ok := ep["port"]; ok {
+		out["port"] = v
+	}
+	if v, ok := ep["hostname"]; ok {
+		out["domain"] = v
+	}
+	if v, ok := ep["user"]; ok {
+		out["user"] = map[string]interface{}{"name": v}
+	}
+	return out
+}
+
+// escapeHeader escapes CEF and LEEF header fields: backslashes and pipes
+// are escaped and line breaks, which would split the record, become spaces.
+func escapeHeader(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '\\', '|':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// escapeCEFValue escapes CEF extension values: backslashes and equal signs
+// are escaped and line breaks are written as \n and \r.
+func escapeCEFValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// escapeLEEFValue escapes LEEF attribute values: the tab delimiter and line
+// breaks are written as \t, \n and \r, and backslashes are escaped so the
+// sequences stay unambiguous.
+func escapeLEEFValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// camelKey turns snake_case field keys into the alphanumeric keys CEF and
+// LEEF parsers accept, e.g. correlation_id becomes correlationId.
+func camelKey(k string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range k {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			if upper && b.Len() > 0 && c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			b.WriteRune(c)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	if b.Len() == 0 {
+		return "field"
+	}
+	return b.String()
+}
+
+// stringify renders a field value; composite values become JSON.
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
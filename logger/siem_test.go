@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/ports"
+)
+
+func TestSIEMEscaping(t *testing.T) {
+	for _, tc := range []struct {
+		name, got, want string
+	}{
+		{"header pipe", escapeHeader(`a|b\c`), `a\|b\\c`},
+		{"header newline", escapeHeader("a\nb"), "a b"},
+		{"cef equals", escapeCEFValue(`k=v\x`), `k\=v\\x`},
+		{"cef newline", escapeCEFValue("a\r\nb\nc"), `a\nb\nc`},
+		{"cef pipe kept", escapeCEFValue("a|b"), "a|b"},
+		{"leef tab", escapeLEEFValue("a\tb=c"), `a\tb=c`},
+		{"leef newline", escapeLEEFValue("a\\\nb"), `a\\\nb`},
+		{"camel key", camelKey("correlation_id"), "correlationId"},
+		{"camel key symbols", camelKey("x-forwarded.for"), "xForwardedFor"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func siemEvent() ports.SecurityEvent {
+	return ports.SecurityEvent{
+		Event:       "auth_bruteforce",
+		Severity:    ports.SeverityHigh,
+		Category:    ports.CategoryAuthentication,
+		Outcome:     ports.OutcomeDenied,
+		Source:      ports.SecurityEndpoint{IP: "10.0.0.1", Port: 51515, User: "alice"},
+		Destination: ports.SecurityEndpoint{Hostname: "api.internal", Port: 443},
+		Technique:   "T1110.001",
+		RuleID:      "R-42",
+		Reason:      "5 failures | window=60s",
+		Fields:      []ports.Field{{Key: "tenant_id", Value: "acme"}},
+	}
+}
+
+func TestWithSinkFormat_SecurityEvent(t *testing.T) {
+	cef, leef, ecs := &memorySink{}, &memorySink{}, &memorySink{}
+	l, err := New("scanner", "info", WithLogDir(t.TempDir()),
+		WithSinkFormat(cef, FormatCEF, StreamSecurity),
+		WithSinkFormat(leef, FormatLEEF, StreamSecurity),
+		WithSinkFormat(ecs, FormatECS, StreamSecurity),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.LogSecurityEvent(context.WithValue(context.Background(), "correlation_id", "c-1"), siemEvent())
+	_ = l.Sync()
+
+	if len(cef.lines) != 1 || len(leef.lines) != 1 || len(ecs.lines) != 1 {
+		t.Fatalf("expected one entry per sink, got %d/%d/%d", len(cef.lines), len(leef.lines), len(ecs.lines))
+	}
+
+	line := cef.lines[0]
+	if !strings.HasPrefix(line, `CEF:0|SecDuckOps|scanner|1.0|auth_bruteforce|5 failures \| window=60s|8|`) {
+		t.Errorf("unexpected CEF header: %s", line)
+	}
+	for _, want := range []string{"src=10.0.0.1", "spt=51515", "suser=alice", "dhost=api.internal", "dpt=443",
+		"cat=authentication", "cs1Label=mitreTechnique cs1=T1110.001", "cs2Label=ruleId cs2=R-42",
+		`reason=5 failures | window\=60s`, "externalId=c-1", "cs3Label=tenantId cs3=acme"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("CEF entry missing %q: %s", want, line)
+		}
+	}
+	if strings.Contains(line, "service=") {
+		t.Errorf("CEF entry carries the custom service key: %s", line)
+	}
+
+	line = strings.TrimSuffix(leef.lines[0], "\n")
+	if !strings.HasPrefix(line, "LEEF:1.0|SecDuckOps|scanner|1.0|auth_bruteforce|sev=8\t") {
+		t.Errorf("unexpected LEEF header: %s", line)
+	}
+	attrs := make(map[string]string)
+	for _, kv := range strings.Split(line[strings.Index(line, "sev="):], "\t") {
+		k, v, _ := strings.Cut(kv, "=")
+		attrs[k] = v
+	}
+	if attrs["src"] != "10.0.0.1" || attrs["usrName"] != "alice" || attrs["technique"] != "T1110.001" || attrs["ruleId"] != "R-42" {
+		t.Errorf("unexpected LEEF attributes: %v", attrs)
+	}
+
+	var doc struct {
+		Event struct {
+			Action   string   `json:"action"`
+			Kind     string   `json:"kind"`
+			Category []string `json:"category"`
+			Severity int      `json:"severity"`
+			Outcome  string   `json:"outcome"`
+		} `json:"event"`
+		Source struct {
+			IP   string `json:"ip"`
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"source"`
+		Destination struct {
+			Domain string `json:"domain"`
+		} `json:"destination"`
+		Threat struct {
+			Technique struct {
+				ID []string `json:"id"`
+			} `json:"technique"`
+		} `json:"threat"`
+		Service struct {
+			Name string `json:"name"`
+		} `json:"service"`
+		Trace struct {
+			ID string `json:"id"`
+		} `json:"trace"`
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal([]byte(ecs.lines[0]), &doc); err != nil {
+		t.Fatalf("invalid ECS document: %v", err)
+	}
+	if doc.Event.Action != "auth_bruteforce" || doc.Event.Kind != "alert" || doc.Event.Severity != 8 ||
+		doc.Event.Outcome != "failure" || len(doc.Event.Category) != 1 || doc.Event.Category[0] != "authentication" {
+		t.Errorf("unexpected ECS event: %+v", doc.Event)
+	}
+	if doc.Source.IP != "10.0.0.1" || doc.Source.User.Name != "alice" || doc.Destination.Domain != "api.internal" {
+		t.Errorf("unexpected ECS endpoints: %+v %+v", doc.Source, doc.Destination)
+	}
+	if len(doc.Threat.Technique.ID) != 1 || doc.Threat.Technique.ID[0] != "T1110.001" || doc.Service.Name != "scanner" ||
+		doc.Trace.ID != "c-1" || doc.Labels["tenant_id"] != "acme" {
+		t.Errorf("unexpected ECS document: %s", ecs.lines[0])
+	}
+}
+
+func TestWithSinkFormat_AppStreamUsesLevelSeverity(t *testing.T) {
+	cef := &memorySink{}
+	l, err := New("scanner", "info", WithLogDir(t.TempDir()), WithSinkFormat(cef, FormatCEF, StreamApp),
+		WithSIEMHeader(SIEMHeader{Vendor: "Acme", Product: "Duck|Ops"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "agent_start", "line one\nline two")
+	l.Debug(context.Background(), "agent_tick", "below the level")
+	_ = l.Sync()
+
+	if len(cef.lines) != 1 {
+		t.Fatalf("expected one entry, got %v", cef.lines)
+	}
+	if want := `CEF:0|Acme|Duck\|Ops|1.0|agent_start|line one line two|3|`; !strings.HasPrefix(cef.lines[0], want) {
+		t.Errorf("expected prefix %q, got %s", want, cef.lines[0])
+	}
+	if !strings.Contains(cef.lines[0], `msg=line one\nline two`) {
+		t.Errorf("expected escaped message, got %s", cef.lines[0])
+	}
+}
+
+func TestLogSecurity_KeepsLegacyShape(t *testing.T) {
+	dir := t.TempDir()
+	cef := &memorySink{}
+	l, err := New("scanner", "info", WithLogDir(dir), WithSinkFormat(cef, FormatCEF, StreamSecurity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.LogSecurity(context.Background(), "auth_failed", "10.0.0.1", "bad password")
+	_ = l.Sync()
+
+	entries := readEntries(t, filepath.Join(dir, "security.log"))
+	if len(entries) != 1 {
+		t.Fatalf("expected one security entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e["ip"] != "10.0.0.1" || e["reason"] != "bad password" || e["msg"] != "Security Event" {
+		t.Errorf("unexpected legacy entry: %v", e)
+	}
+	if _, ok := e["source"]; ok {
+		t.Errorf("legacy entry gained a source object: %v", e)
+	}
+	if _, ok := e["category"]; ok {
+		t.Errorf("legacy entry gained a category: %v", e)
+	}
+	if len(cef.lines) != 1 || !strings.Contains(cef.lines[0], "src=10.0.0.1") {
+		t.Errorf("CEF entry should take its source from ip: %v", cef.lines)
+	}
+}
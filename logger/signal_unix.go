@@ -0,0 +1,45 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// HandleSignals reopens the log files on SIGHUP, for logrotate's
+// postrotate hook, and forces a rotation on SIGUSR1. The returned function
+// stops handling; Close stops it as well.
+func (l *Logger) HandleSignals() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGHUP, syscall.SIGUSR1)
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				var err error
+				if sig == syscall.SIGUSR1 {
+					err = l.Rotate()
+				} else {
+					err = l.Reopen()
+				}
+				if err != nil && err != ErrClosed {
+					l.zap.Warn("Log file signal handling failed",
+						zap.String("event", "log_signal_failed"), zap.Error(err))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return l.registerSignalStop(func() {
+		signal.Stop(ch)
+		close(done)
+	})
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLogger_HandleSignalsReopensOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	stop := l.HandleSignals()
+	defer stop()
+
+	ctx := context.Background()
+	l.Info(ctx, "agent_start", "before")
+	name := filepath.Join(dir, "app.log")
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler runs asynchronously; keep logging until the new file appears.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		l.Info(ctx, "agent_tick", "after")
+		if _, err := os.Stat(name); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("app.log was not reopened after SIGHUP")
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package logger
+
+// HandleSignals is a no-op on Windows, which has no SIGHUP or SIGUSR1; call
+// Reopen or Rotate directly instead.
+func (l *Logger) HandleSignals() (stop func()) {
+	return l.registerSignalStop(func() {})
+}
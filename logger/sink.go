@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink ships encoded entries somewhere besides the local log files, e.g. to
+// Elasticsearch or a syslog collector. Write receives exactly one encoded
+// entry per call and must not retain p.
+type Sink interface {
+	zapcore.WriteSyncer
+	Close() error
+}
+
+// WithSink routes the given streams to sink in addition to their files.
+// Without streams the sink receives every stream.
+func WithSink(sink Sink, streams ...Stream) Option {
+	return func(o *options) {
+		if len(streams) == 0 {
+			streams = []Stream{StreamApp, StreamError, StreamAudit, StreamSecurity}
+		}
+		for _, s := range streams {
+			o.sinks[s] = append(o.sinks[s], sink)
+		}
+	}
+}
+
+// fanoutWriter writes every entry to the local file first and then to the
+// sinks of the stream. Only file errors are returned: sinks buffer, retry and
+// account for their own failures, and an unreachable collector must neither
+// fail local logging nor desynchronize the audit chain.
+type fanoutWriter struct {
+	primary zapcore.WriteSyncer
+	sinks   []Sink
+}
+
+func newFanoutWriter(primary zapcore.WriteSyncer, sinks []Sink) zapcore.WriteSyncer {
+	if len(sinks) == 0 {
+		return primary
+	}
+	return &fanoutWriter{primary: primary, sinks: sinks}
+}
+
+func (w *fanoutWriter) Write(p []byte) (int, error) {
+	n, err := w.primary.Write(p)
+	for _, s := range w.sinks {
+		_, _ = s.Write(p)
+	}
+	return n, err
+}
+
+func (w *fanoutWriter) Sync() error {
+	err := w.primary.Sync()
+	for _, s := range w.sinks {
+		_ = s.Sync()
+	}
+	return err
+}
+
+// uniqueSinks lists every configured sink once, in first-seen stream order.
+func uniqueSinks(byStream map[Stream][]Sink, formatted map[Stream][]formattedSink) []Sink {
+	var out []Sink
+	seen := make(map[Sink]bool)
+	add := func(s Sink) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	for _, stream := range []Stream{StreamApp, StreamError, StreamAudit, StreamSecurity} {
+		for _, s := range byStream[stream] {
+			add(s)
+		}
+		for _, fs := range formatted[stream] {
+			add(fs.sink)
+		}
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestElasticsearchSink_BatchesAndRetries(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		docs      []string
+		requests  atomic.Int32
+		throttled atomic.Bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if !throttled.Swap(true) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		mu.Lock()
+		for i := 1; i < len(lines); i += 2 {
+			docs = append(docs, lines[i])
+		}
+		mu.Unlock()
+		io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	defer srv.Close()
+
+	sink, err := NewElasticsearchSink(ElasticsearchConfig{
+		URL:           srv.URL,
+		Index:         "duckops-audit",
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 3; i++ {
+		sink.Write([]byte(`{"msg":"entry ` + strconv.Itoa(i) + `"}` + "\n"))
+	}
+	sink.Sync()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(docs) != 3 || docs[0] != `{"msg":"entry 0"}` {
+		t.Errorf("unexpected indexed docs: %v", docs)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected one throttled request and one retry, got %d", requests.Load())
+	}
+	if st := sink.Stats(); st.Indexed != 3 || st.Retries != 1 || st.Failed != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestElasticsearchSink_UndecodableSuccessIsNotResent(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.Copy(io.Discard, r.Body)
+		io.WriteString(w, `{"errors":fal`)
+	}))
+	defer srv.Close()
+
+	sink, err := NewElasticsearchSink(ElasticsearchConfig{URL: srv.URL, Index: "duckops-audit", FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Write([]byte(`{"msg":"entry"}` + "\n"))
+	sink.Sync()
+
+	if requests.Load() != 1 {
+		t.Errorf("expected a single request, got %d", requests.Load())
+	}
+	if st := sink.Stats(); st.Unconfirmed != 1 || st.Retries != 0 || st.Failed != 0 || st.LastError == nil {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "udp", Address: pc.LocalAddr().String(), Hostname: "host-1", Facility: FacilityAudit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Write([]byte(`{"level":"warn","timestamp":"2026-01-02T03:04:05.123Z","event":"auth failed","msg":"x"}` + "\n"))
+
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+
+	// facility 13 * 8 + warning 4 = 108; MSGID drops the space.
+	if !strings.HasPrefix(msg, "<108>1 2026-01-02T03:04:05.123000Z ") || !strings.Contains(msg, " host-1 duckops ") || !strings.Contains(msg, " authfailed - {") {
+		t.Errorf("unexpected syslog message: %q", msg)
+	}
+}
+
+func TestSyslogSink_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		lenStr, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(lenStr))
+		msg := make([]byte, n)
+		io.ReadFull(r, msg)
+		received <- string(msg)
+	}()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "tcp", Address: ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.Write([]byte(`{"level":"info","event":"agent_start"}` + "\n"))
+
+	select {
+	case msg := <-received:
+		if !strings.HasPrefix(msg, "<134>1 ") || !strings.HasSuffix(msg, `agent_start - {"level":"info","event":"agent_start"}`) {
+			t.Errorf("unexpected framed message: %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestSyslogSink_StalledCollectorTimesOut(t *testing.T) {
+	// The listener never reads, so the socket buffers fill up.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "tcp", Address: ln.Addr().String(), WriteTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	big := `{"level":"info","event":"dump","msg":"` + strings.Repeat("x", 32<<20) + `"}`
+	done := make(chan error, 1)
+	go func() {
+		_, err := sink.Write([]byte(big))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the stalled write to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("write blocked on a stalled collector")
+	}
+}
+
+type memorySink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (m *memorySink) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lines = append(m.lines, string(p))
+	return len(p), nil
+}
+func (m *memorySink) Sync() error  { return nil }
+func (m *memorySink) Close() error { return nil }
+
+func TestWithSink_RoutesStreamsIndependently(t *testing.T) {
+	audit := &memorySink{}
+	app := &memorySink{}
+	l, err := New("test", "info", WithLogDir(t.TempDir()), WithSink(audit, StreamAudit), WithSink(app, StreamApp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	l.Info(ctx, "agent_start", "started")
+	l.LogAudit(ctx, "resource_created", "alice", "create", "scan-1")
+	l.LogSecurity(ctx, "auth_failed", "10.0.0.1", "bad password")
+	_ = l.Sync()
+
+	if len(app.lines) != 1 || !strings.Contains(app.lines[0], "agent_start") {
+		t.Errorf("app sink got %v", app.lines)
+	}
+	if len(audit.lines) != 1 || !strings.Contains(audit.lines[0], `"chain_hash"`) {
+		t.Errorf("audit sink should receive the chained entry, got %v", audit.lines)
+	}
+}
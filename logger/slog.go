@@ -0,0 +1,302 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/SecDuckOps/shared/ports"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogOptions configures the slog.Handler returned by Logger.SlogHandler.
+type SlogOptions struct {
+	// Event is logged for records without an "event" attribute
+	// (default "slog_record"). Register it when a catalog is strict.
+	Event string
+}
+
+// SlogHandler returns an slog.Handler that routes records of libraries
+// logging through log/slog into the logger, so they share its streams,
+// redaction and correlation IDs. Group names prefix attribute keys with
+// dots, e.g. "db.query". Error records carrying an error-valued "err" or
+// "error" attribute are logged through ErrorErr.
+func (l *Logger) SlogHandler(opts SlogOptions) slog.Handler {
+	if opts.Event == "" {
+		opts.Event = "slog_record"
+	}
+	return &slogHandler{l: l, event: opts.Event}
+}
+
+type slogHandler struct {
+	l     *Logger
+	event string
+	attrs []ports.Field
+	group string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.zap.Core().Enabled(zapLevelFromSlog(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	// "event" and error attributes are recognized by their own key, even
+	// below a WithGroup, since libraries do not know the handler's groups.
+	event := h.event
+	var err error
+	fields := make([]ports.Field, len(h.attrs), len(h.attrs)+r.NumAttrs())
+	copy(fields, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		switch v := a.Value.Resolve().Any().(type) {
+		case string:
+			if a.Key == "event" {
+				event = v
+				return true
+			}
+		case error:
+			if err == nil && (a.Key == "err" || a.Key == "error") && r.Level >= slog.LevelError {
+				err = v
+				return true
+			}
+		}
+		fields = appendSlogAttr(fields, h.group, a)
+		return true
+	})
+
+	if r.Level >= slog.LevelError {
+		h.l.ErrorErr(ctx, event, err, r.Message, fields...)
+		return nil
+	}
+	h.l.checkEvent(event, fields)
+	zapFields := h.l.toZapFields(fields)
+	zapFields = append(zapFields, zap.String("event", event))
+	h.l.zap.Log(zapLevelFromSlog(r.Level), h.l.redactor.RedactString(r.Message), h.l.withContextFields(ctx, zapFields)...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]ports.Field, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(clone.attrs, h.attrs)
+	for _, a := range attrs {
+		clone.attrs = appendSlogAttr(clone.attrs, h.group, a)
+	}
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = joinKey(h.group, name)
+	return &clone
+}
+
+// appendSlogAttr flattens an attribute into fields, expanding groups into
+// dotted keys. Empty attributes are dropped as slog handlers must.
+func appendSlogAttr(fields []ports.Field, prefix string, a slog.Attr) []ports.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// Inline groups (empty key) keep the current prefix.
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = joinKey(prefix, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendSlogAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+	return append(fields, ports.Field{Key: joinKey(prefix, a.Key), Value: a.Value.Any()})
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func zapLevelFromSlog(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+func slogLevelFromZap(level zapcore.Level) slog.Level {
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return slog.LevelError
+	case level >= zapcore.WarnLevel:
+		return slog.LevelWarn
+	case level >= zapcore.InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// SlogLogger implements ports.Logger on top of any slog.Handler, for
+// consumers whose logs are already collected through log/slog. Audit and
+// security entries carry a "stream" attribute so the handler can route them.
+type SlogLogger struct {
+	handler  slog.Handler
+	redactor *Redactor
+}
+
+var _ ports.Logger = (*SlogLogger)(nil)
+
+// NewSlogLogger wraps handler. A nil redactor disables redaction.
+func NewSlogLogger(handler slog.Handler, redactor *Redactor) *SlogLogger {
+	return &SlogLogger{handler: handler, redactor: redactor}
+}
+
+// Debug logs a debug message with context fields.
+func (s *SlogLogger) Debug(ctx context.Context, event string, msg string, fields ...ports.Field) {
+	s.log(ctx, slog.LevelDebug, msg, s.attrs(event, fields))
+}
+
+// Info logs an info message with context fields.
+func (s *SlogLogger) Info(ctx context.Context, event string, msg string, fields ...ports.Field) {
+	s.log(ctx, slog.LevelInfo, msg, s.attrs(event, fields))
+}
+
+// ErrorErr logs an error with the same level mapping and error keys as Logger.
+func (s *SlogLogger) ErrorErr(ctx context.Context, event string, err error, msg string, fields ...ports.Field) {
+	attrs := s.attrs(event, fields)
+	if err != nil {
+		attrs = append(attrs, s.errorSlogAttrs(err)...)
+	}
+	s.log(ctx, slogLevelFromZap(ErrorLevel(err)), msg, attrs)
+}
+
+// LogAuditEvent logs a structured audit event with stream=audit.
+func (s *SlogLogger) LogAuditEvent(ctx context.Context, event ports.AuditEvent) {
+	outcome := string(event.Outcome)
+	if outcome == "" {
+		outcome = "unknown"
+	}
+
+	actor := []any{slog.String("id", event.Actor.ID), slog.String("kind", string(event.Actor.Kind))}
+	if event.Actor.IP != "" {
+		actor = append(actor, slog.String("ip", event.Actor.IP))
+	}
+
+	attrs := append(s.attrs(event.Event, event.Fields),
+		slog.String("stream", string(StreamAudit)),
+		slog.Group("actor", actor...),
+		slog.String("action", string(event.Action)),
+		slog.Group("resource", slog.String("type", event.Resource.Type), slog.String("id", event.Resource.ID)),
+		slog.String("outcome", outcome),
+	)
+	if event.Reason != "" {
+		attrs = append(attrs, slog.String("reason", s.redactor.RedactString(event.Reason)))
+	}
+	if len(event.Changes) > 0 {
+		changes := make([]ports.AuditChange, len(event.Changes))
+		for i, c := range event.Changes {
+			changes[i] = ports.AuditChange{
+				Field:  c.Field,
+				Before: s.redactor.Redact(c.Field, c.Before),
+				After:  s.redactor.Redact(c.Field, c.After),
+			}
+		}
+		attrs = append(attrs, slog.Any("changes", changes))
+	}
+	if event.Request != nil {
+		attrs = append(attrs, slog.Any("request", *event.Request))
+	}
+	s.log(ctx, slog.LevelInfo, "Audit Event", attrs)
+}
+
+// LogAudit logs an important system action with stream=audit.
+//
+// Deprecated: use LogAuditEvent.
+func (s *SlogLogger) LogAudit(ctx context.Context, event string, actor string, action string, resource string, fields ...ports.Field) {
+	s.LogAuditEvent(ctx, ports.AuditEvent{
+		Event:    event,
+		Actor:    ports.AuditActor{ID: actor},
+		Action:   ports.AuditAction(action),
+		Resource: ports.AuditResource{ID: resource},
+		Fields:   fields,
+	})
+}
+
+// LogSecurityEvent logs a structured security event with stream=security.
+func (s *SlogLogger) LogSecurityEvent(ctx context.Context, event ports.SecurityEvent) {
+	severity := event.Severity
+	if severity == "" {
+		severity = ports.SeverityMedium
+	}
+
+	attrs := append(s.attrs(event.Event, event.Fields),
+		slog.String("stream", string(StreamSecurity)),
+		slog.String("severity", string(severity)),
+		slog.String("category", string(event.Category)),
+		slog.Any("source", event.Source),
+		slog.String("reason", s.redactor.RedactString(event.Reason)),
+	)
+	if !event.Destination.IsZero() {
+		attrs = append(attrs, slog.Any("destination", event.Destination))
+	}
+	if event.Outcome != "" {
+		attrs = append(attrs, slog.String("outcome", string(event.Outcome)))
+	}
+	if event.Technique != "" {
+		attrs = append(attrs, slog.String("technique", event.Technique))
+	}
+	if event.RuleID != "" {
+		attrs = append(attrs, slog.String("rule_id", event.RuleID))
+	}
+	s.log(ctx, slog.LevelWarn, "Security Event", attrs)
+}
+
+// LogSecurity logs a security-related event with stream=security.
+//
+// Deprecated: use LogSecurityEvent.
+func (s *SlogLogger) LogSecurity(ctx context.Context, event string, ip string, reason string, fields ...ports.Field) {
+	s.LogSecurityEvent(ctx, ports.SecurityEvent{
+		Event:    event,
+		Severity: ports.SeverityMedium,
+		Source:   ports.SecurityEndpoint{IP: ip},
+		Reason:   reason,
+		Fields:   fields,
+	})
+}
+
+// attrs converts the event and port fields, redacting secrets.
+func (s *SlogLogger) attrs(event string, fields []ports.Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields)+8)
+	attrs = append(attrs, slog.String("event", event))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, s.redactor.Redact(f.Key, f.Value)))
+	}
+	return attrs
+}
+
+func (s *SlogLogger) log(ctx context.Context, level slog.Level, msg string, attrs []slog.Attr) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !s.handler.Enabled(ctx, level) {
+		return
+	}
+	if cid, ok := ctx.Value("correlation_id").(string); ok {
+		attrs = append(attrs, slog.String("correlation_id", cid))
+	}
+	r := slog.NewRecord(time.Now(), level, s.redactor.RedactString(msg), 0)
+	r.AddAttrs(attrs...)
+	_ = s.handler.Handle(ctx, r)
+}
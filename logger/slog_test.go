@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/shared/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+func TestSlogHandler_RoutesIntoLogger(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), "correlation_id", "c-7")
+	sl := slog.New(l.SlogHandler(SlogOptions{})).With("component", "db").WithGroup("req")
+	sl.InfoContext(ctx, "query done", "ms", 5, slog.Group("auth", "password", "hunter2"))
+	sl.DebugContext(ctx, "below the level")
+	sl.ErrorContext(ctx, "lookup failed", "event", "operation_failed", "err", types.New(types.ErrCodeNotFound, "scan missing"))
+	sl.Error("boom", "err", types.New(types.ErrCodeInternal, "crash"))
+	_ = l.Sync()
+
+	app := readEntries(t, filepath.Join(dir, "app.log"))
+	if len(app) != 2 {
+		t.Fatalf("expected info and mapped warn entries in app.log, got %v", app)
+	}
+	info := app[0]
+	if info["event"] != "slog_record" || info["component"] != "db" || info["req.ms"] != float64(5) || info["correlation_id"] != "c-7" {
+		t.Errorf("unexpected info entry %v", info)
+	}
+	if info["req.auth.password"] == "hunter2" {
+		t.Error("grouped secret was not redacted")
+	}
+	warn := app[1]
+	if warn["level"] != "warn" || warn["event"] != "operation_failed" || warn["error_code"] != string(types.ErrCodeNotFound) {
+		t.Errorf("expected AppError level mapping, got %v", warn)
+	}
+
+	errs := readEntries(t, filepath.Join(dir, "error.log"))
+	if len(errs) != 1 || errs[0]["error_code"] != string(types.ErrCodeInternal) {
+		t.Errorf("unexpected error entries %v", errs)
+	}
+}
+
+func TestSlogLogger_ImplementsPort(t *testing.T) {
+	var buf bytes.Buffer
+	var log ports.Logger = NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), NewRedactor(RedactionConfig{}))
+
+	ctx := context.WithValue(context.Background(), "correlation_id", "c-9")
+	log.Info(ctx, "agent_start", "started", ports.Field{Key: "api_key", Value: "sk-live-1234567890"})
+	log.ErrorErr(ctx, "operation_failed", types.New(types.ErrCodeInvalidInput, "bad input"), "rejected")
+	log.LogAuditEvent(ctx, ports.AuditEvent{
+		Event:    "resource_deleted",
+		Actor:    ports.AuditActor{ID: "alice", Kind: ports.ActorUser},
+		Action:   ports.ActionDelete,
+		Resource: ports.AuditResource{Type: "scan", ID: "s-1"},
+		Outcome:  ports.OutcomeSuccess,
+	})
+	log.LogSecurity(ctx, "auth_failed", "10.0.0.1", "bad password")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, m)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(entries))
+	}
+
+	if entries[0]["event"] != "agent_start" || entries[0]["api_key"] == "sk-live-1234567890" || entries[0]["correlation_id"] != "c-9" {
+		t.Errorf("unexpected info record %v", entries[0])
+	}
+	if entries[1]["level"] != "WARN" || entries[1]["error_code"] != string(types.ErrCodeInvalidInput) {
+		t.Errorf("unexpected error record %v", entries[1])
+	}
+	actor, _ := entries[2]["actor"].(map[string]interface{})
+	if entries[2]["stream"] != "audit" || actor["id"] != "alice" || entries[2]["outcome"] != "success" {
+		t.Errorf("unexpected audit record %v", entries[2])
+	}
+	source, _ := entries[3]["source"].(map[string]interface{})
+	if entries[3]["stream"] != "security" || entries[3]["level"] != "WARN" || source["ip"] != "10.0.0.1" {
+		t.Errorf("unexpected security record %v", entries[3])
+	}
+}
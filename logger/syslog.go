@@ -0,0 +1,247 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Syslog facilities commonly used for application logs (RFC 5424 §6.2.1).
+const (
+	FacilityAuth   = 4
+	FacilityAudit  = 13
+	FacilityLocal0 = 16
+	FacilityLocal7 = 23
+)
+
+const (
+	syslogNilValue  = "-"
+	syslogMaxMsgID  = 32
+	syslogMaxAppLen = 48
+)
+
+// SyslogConfig configures a SyslogSink.
+type SyslogConfig struct {
+	// Network is "udp", "tcp" or "tls".
+	Network string
+	Address string
+	// TLSConfig is used when Network is "tls".
+	TLSConfig *tls.Config
+
+	// Facility defaults to FacilityLocal0.
+	Facility int
+	// AppName defaults to "duckops".
+	AppName string
+	// Hostname defaults to os.Hostname().
+	Hostname string
+
+	DialTimeout time.Duration
+	// WriteTimeout bounds each write so a stalled collector cannot block
+	// logging; a timed-out stream connection is redialed. Default 5s.
+	WriteTimeout time.Duration
+}
+
+// SyslogSink forwards entries as RFC 5424 messages. TCP and TLS use octet
+// counting framing (RFC 6587), UDP sends one message per datagram.
+type SyslogSink struct {
+	cfg    SyslogConfig
+	procID string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewSyslogSink dials the collector.
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	switch cfg.Network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", cfg.Network)
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = FacilityLocal0
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "duckops"
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 5 * time.Second
+	}
+
+	s := &SyslogSink{cfg: cfg, procID: strconv.Itoa(os.Getpid())}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) connect() error {
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: s.cfg.DialTimeout}
+	if s.cfg.Network == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.cfg.Address, s.cfg.TLSConfig)
+	} else {
+		conn, err = dialer.Dial(s.cfg.Network, s.cfg.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog %s://%s: %w", s.cfg.Network, s.cfg.Address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Write formats one encoded entry as a syslog message, reconnecting once
+// when a stream connection was dropped or timed out.
+func (s *SyslogSink) Write(p []byte) (int, error) {
+	msg := s.format(bytes.TrimRight(p, "\n"), time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, fmt.Errorf("syslog sink is closed")
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.write(msg); err != nil {
+		if s.cfg.Network == "udp" {
+			return 0, err
+		}
+		// A partial write corrupts the framing, so always start afresh.
+		s.conn.Close()
+		s.conn = nil
+		if err := s.connect(); err != nil {
+			return 0, err
+		}
+		if err := s.write(msg); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// write sends msg on the current connection within the write timeout.
+func (s *SyslogSink) write(msg []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout)); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// Sync is a no-op: messages are written to the connection immediately.
+func (s *SyslogSink) Sync() error {
+	return nil
+}
+
+// Close closes the connection. It is safe to call twice.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// format renders `<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID - MSG`,
+// using the entry's level for the severity, its event as MSGID and its own
+// timestamp, which may predate the write when buffering asynchronously. now
+// stands in for entries without a timestamp.
+func (s *SyslogSink) format(entry []byte, now time.Time) []byte {
+	var meta struct {
+		Level     string `json:"level"`
+		Event     string `json:"event"`
+		Timestamp string `json:"timestamp"`
+	}
+	_ = json.Unmarshal(entry, &meta)
+	if ts, err := time.Parse(entryTimeFormat, meta.Timestamp); err == nil {
+		now = ts
+	}
+
+	pri := s.cfg.Facility*8 + syslogSeverity(meta.Level)
+
+	var b bytes.Buffer
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(pri))
+	b.WriteString(">1 ")
+	b.WriteString(now.UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(s.cfg.Hostname, 255))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(s.cfg.AppName, syslogMaxAppLen))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(s.procID, 128))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(meta.Event, syslogMaxMsgID))
+	b.WriteString(" - ")
+	b.Write(entry)
+
+	if s.cfg.Network == "udp" {
+		return b.Bytes()
+	}
+	framed := make([]byte, 0, b.Len()+8)
+	framed = strconv.AppendInt(framed, int64(b.Len()), 10)
+	framed = append(framed, ' ')
+	return append(framed, b.Bytes()...)
+}
+
+// syslogSeverity maps zap level names onto RFC 5424 severities.
+func syslogSeverity(level string) int {
+	switch level {
+	case "debug":
+		return 7
+	case "info":
+		return 6
+	case "warn":
+		return 4
+	case "error":
+		return 3
+	case "dpanic", "panic":
+		return 2
+	case "fatal":
+		return 1
+	default:
+		return 5
+	}
+}
+
+// syslogHeaderField restricts a header value to printable US-ASCII without
+// spaces, as required by the RFC, falling back to the NILVALUE.
+func syslogHeaderField(v string, max int) string {
+	out := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(out) < max; i++ {
+		if c := v[i]; c > 32 && c < 127 {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return syslogNilValue
+	}
+	return string(out)
+}
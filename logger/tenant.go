@@ -0,0 +1,375 @@
+package logger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// QuarantineTenant is the directory under tenants/ that receives routed
+// entries whose context carries no valid tenant ID.
+const QuarantineTenant = "_quarantine"
+
+// tenantIDPattern accepts tenant IDs that are safe as a directory name. The
+// leading underscore is reserved for QuarantineTenant.
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// TenantConfig enables per-tenant routing. Entries of the routed streams are
+// written to <logDir>/tenants/<tenant_id>/<stream>.log, chosen by the
+// "tenant_id" context value; every tenant audit file carries its own hash
+// chain. Entries without a valid tenant go to the QuarantineTenant directory,
+// never to another tenant's file.
+type TenantConfig struct {
+	// Streams lists the routed streams (default audit and security). Only
+	// the audit and security streams can be routed; New rejects others.
+	Streams []Stream
+	// MaxOpen caps the tenant files open at once (default 64). The least
+	// recently used file is closed beyond the cap and reopened, resuming its
+	// chain, when its tenant logs again. Quarantine files do not count.
+	MaxOpen int
+	// Default applies to tenants without an entry in Policies
+	// (default DefaultRotationPolicy).
+	Default  *RotationPolicy
+	Policies map[string]RotationPolicy
+	// SinkFactory optionally creates a dedicated sink per tenant and stream,
+	// closed together with the tenant file. Sinks registered with WithSink
+	// and WithSinkFormat only receive the quarantined entries of routed
+	// streams, so no tenant's entries reach a shared destination.
+	SinkFactory func(tenantID string, stream Stream) (Sink, error)
+}
+
+// WithTenantRouting routes the audit and security streams into per-tenant
+// files. Routed streams bypass WithAsync buffering.
+func WithTenantRouting(cfg TenantConfig) Option {
+	return func(o *options) {
+		o.tenants = &cfg
+	}
+}
+
+// TenantIDFromContext returns the "tenant_id" context value, if any.
+func TenantIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value("tenant_id").(string)
+	return id, ok && id != ""
+}
+
+// tenantFile is one open tenant stream file.
+type tenantFile struct {
+	key    string
+	elem   *list.Element
+	zap    *zap.Logger
+	file   *lumberjack.Logger
+	sink   Sink
+	mu     sync.Mutex
+	closed bool
+}
+
+func (f *tenantFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	errs := []error{f.zap.Sync(), f.file.Close()}
+	if f.sink != nil {
+		errs = append(errs, f.sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// tenantRouter owns the tenant files of a Logger.
+type tenantRouter struct {
+	cfg     TenantConfig
+	streams map[Stream]bool
+	logDir  string
+	key     []byte
+	sinks   map[Stream][]Sink
+	closed  *atomic.Bool
+	// core builds the zap core of a stream around a writer, as New does for
+	// the shared stream files; shared adds the formatted sinks.
+	core func(stream Stream, w zapcore.WriteSyncer, shared bool) zapcore.Core
+
+	mu      sync.Mutex
+	files   map[string]*tenantFile
+	opening map[string]*tenantOpen
+	lru     *list.List // most recently used first
+}
+
+// tenantOpen is a tenant file being created or evicted outside the router
+// lock. Other callers for the same key wait on done instead of creating it
+// again, so a key never has two handles appending to its chain.
+type tenantOpen struct {
+	done chan struct{}
+	err  error
+}
+
+func newTenantRouter(cfg TenantConfig, o options, closed *atomic.Bool, core func(Stream, zapcore.WriteSyncer, bool) zapcore.Core) *tenantRouter {
+	if len(cfg.Streams) == 0 {
+		cfg.Streams = []Stream{StreamAudit, StreamSecurity}
+	}
+	if cfg.MaxOpen <= 0 {
+		cfg.MaxOpen = 64
+	}
+	r := &tenantRouter{
+		cfg:     cfg,
+		streams: make(map[Stream]bool),
+		logDir:  filepath.Join(o.logDir, "tenants"),
+		key:     o.auditKey,
+		sinks:   o.sinks,
+		closed:  closed,
+		core:    core,
+		files:   make(map[string]*tenantFile),
+		opening: make(map[string]*tenantOpen),
+		lru:     list.New(),
+	}
+	for _, s := range cfg.Streams {
+		r.streams[s] = true
+	}
+	return r
+}
+
+func (r *tenantRouter) policy(tenant string) RotationPolicy {
+	if p, ok := r.cfg.Policies[tenant]; ok {
+		return p
+	}
+	if r.cfg.Default != nil {
+		return *r.cfg.Default
+	}
+	return DefaultRotationPolicy
+}
+
+// log writes an entry to the tenant file ctx routes to. Entries of a valid
+// tenant are tagged with tenant_id, quarantined ones with quarantine_reason.
+func (r *tenantRouter) log(ctx context.Context, stream Stream, level zapcore.Level, msg string, fields []zap.Field) error {
+	tenant, ok := TenantIDFromContext(ctx)
+	switch {
+	case !ok:
+		tenant = QuarantineTenant
+		fields = append(fields, zap.String("quarantine_reason", "missing tenant_id"))
+	case !tenantIDPattern.MatchString(tenant):
+		tenant = QuarantineTenant
+		fields = append(fields, zap.String("quarantine_reason", "invalid tenant_id"))
+	default:
+		if !hasField(fields, "tenant_id") {
+			fields = append(fields, zap.String("tenant_id", tenant))
+		}
+	}
+
+	// A file evicted between lookup and write is closed; look it up again.
+	for {
+		f, err := r.open(tenant, stream)
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			continue
+		}
+		f.zap.Log(level, msg, fields...)
+		f.mu.Unlock()
+		return nil
+	}
+}
+
+func (r *tenantRouter) open(tenant string, stream Stream) (*tenantFile, error) {
+	key := tenant + "/" + string(stream)
+
+	r.mu.Lock()
+	for {
+		if r.closed.Load() {
+			r.mu.Unlock()
+			return nil, ErrClosed
+		}
+		if f, ok := r.files[key]; ok {
+			if f.elem != nil {
+				r.lru.MoveToFront(f.elem)
+			}
+			r.mu.Unlock()
+			return f, nil
+		}
+		pending, ok := r.opening[key]
+		if !ok {
+			break
+		}
+		r.mu.Unlock()
+		<-pending.done
+		if pending.err != nil {
+			return nil, pending.err
+		}
+		r.mu.Lock()
+	}
+
+	// Creating the file reads the tail of its chain; other tenants must not
+	// wait for that.
+	pending := &tenantOpen{done: make(chan struct{})}
+	r.opening[key] = pending
+	r.mu.Unlock()
+
+	f, err := r.create(tenant, stream)
+
+	r.mu.Lock()
+	delete(r.opening, key)
+	pending.err = err
+	close(pending.done)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	if r.closed.Load() {
+		r.mu.Unlock()
+		_ = f.close()
+		return nil, ErrClosed
+	}
+	f.key = key
+	r.files[key] = f
+
+	var evicted []*tenantFile
+	var closing []*tenantOpen
+	if tenant != QuarantineTenant {
+		f.elem = r.lru.PushFront(f)
+		for r.lru.Len() > r.cfg.MaxOpen {
+			oldest := r.lru.Remove(r.lru.Back()).(*tenantFile)
+			delete(r.files, oldest.key)
+			// Reopening must wait until the old handle is closed.
+			evict := &tenantOpen{done: make(chan struct{})}
+			r.opening[oldest.key] = evict
+			evicted = append(evicted, oldest)
+			closing = append(closing, evict)
+		}
+	}
+	r.mu.Unlock()
+
+	// Eviction waits for in-flight writes on the file's own lock.
+	for i, old := range evicted {
+		_ = old.close()
+		r.mu.Lock()
+		delete(r.opening, old.key)
+		close(closing[i].done)
+		r.mu.Unlock()
+	}
+	return f, nil
+}
+
+// create assembles a tenant file like New assembles a shared stream: file
+// plus sinks behind the close guard, and the hash chain for audit. Only
+// quarantine files feed the shared sinks.
+func (r *tenantRouter) create(tenant string, stream Stream) (*tenantFile, error) {
+	dir := filepath.Join(r.logDir, tenant)
+	filename := string(stream) + ".log"
+	file, err := newRotator(dir, filename, r.policy(tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	shared := tenant == QuarantineTenant
+	var sinks []Sink
+	if shared {
+		sinks = r.sinks[stream]
+	}
+	var own Sink
+	if r.cfg.SinkFactory != nil && !shared {
+		own, err = r.cfg.SinkFactory(tenant, stream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s sink for tenant %s: %w", stream, tenant, err)
+		}
+		if own != nil {
+			sinks = []Sink{own}
+		}
+	}
+
+	var w zapcore.WriteSyncer = closeGuard{out: newFanoutWriter(zapcore.AddSync(file), sinks), closed: r.closed}
+	if stream == StreamAudit {
+		chain, err := newChainWriter(w, r.key, dir, filename)
+		if err != nil {
+			if own != nil {
+				_ = own.Close()
+			}
+			return nil, err
+		}
+		w = chain
+	}
+	return &tenantFile{zap: zap.New(r.core(stream, w, shared)), file: file, sink: own}, nil
+}
+
+// each applies fn to every open tenant file that is not closed meanwhile.
+func (r *tenantRouter) each(fn func(*tenantFile) error) error {
+	r.mu.Lock()
+	files := make([]*tenantFile, 0, len(r.files))
+	for _, f := range r.files {
+		files = append(files, f)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		f.mu.Lock()
+		if !f.closed {
+			errs = append(errs, fn(f))
+		}
+		f.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (r *tenantRouter) sync() error {
+	return r.each(func(f *tenantFile) error { return f.zap.Sync() })
+}
+
+// eachRotator applies fn to the rotators of the open tenant files.
+func (r *tenantRouter) eachRotator(fn func(*lumberjack.Logger) error) error {
+	return r.each(func(f *tenantFile) error { return fn(f.file) })
+}
+
+// close closes every tenant file. The logger is already marked closed, so
+// no file is opened afterwards.
+func (r *tenantRouter) close() error {
+	r.mu.Lock()
+	files := r.files
+	r.files = make(map[string]*tenantFile)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, f.close())
+	}
+	return errors.Join(errs...)
+}
+
+// routed logs an entry of a lossless stream, through the tenant router when
+// the stream is routed and to the shared stream logger otherwise.
+func (l *Logger) routed(ctx context.Context, stream Stream, shared *zap.Logger, level zapcore.Level, msg string, fields []zap.Field) {
+	if l.tenants == nil || !l.tenants.streams[stream] {
+		shared.Log(level, msg, fields...)
+		return
+	}
+	err := l.tenants.log(ctx, stream, level, msg, fields)
+	if err != nil && !errors.Is(err, ErrClosed) {
+		// Never drop the entry: keep it in the shared stream file instead.
+		fields = append(fields, zap.String("quarantine_reason", "tenant routing failed: "+err.Error()))
+		shared.Log(level, msg, fields...)
+	}
+}
+
+func hasField(fields []zap.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/shared/ports"
+)
+
+func tenantCtx(id string) context.Context {
+	return context.WithValue(context.Background(), "tenant_id", id)
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestTenantRouting_IsolatesAndQuarantines(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithTenantRouting(TenantConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.LogAudit(tenantCtx("acme"), "resource_read", "alice", "read", "scan-1")
+	l.LogAudit(tenantCtx("globex"), "resource_read", "bob", "read", "scan-2")
+	l.LogSecurity(tenantCtx("acme"), "auth_failed", "10.0.0.1", "bad password")
+	l.LogAudit(context.Background(), "resource_read", "carol", "read", "scan-3")
+	l.LogAudit(tenantCtx("../globex"), "resource_read", "mallory", "read", "scan-4")
+	_ = l.Sync()
+
+	tenants := filepath.Join(dir, "tenants")
+	acme := readLines(t, filepath.Join(tenants, "acme", "audit.log"))
+	if len(acme) != 1 || !strings.Contains(acme[0], `"alice"`) || !strings.Contains(acme[0], `"tenant_id":"acme"`) {
+		t.Errorf("unexpected acme audit file: %v", acme)
+	}
+	if sec := readLines(t, filepath.Join(tenants, "acme", "security.log")); len(sec) != 1 || !strings.Contains(sec[0], "auth_failed") {
+		t.Errorf("unexpected acme security file: %v", sec)
+	}
+	if globex := readLines(t, filepath.Join(tenants, "globex", "audit.log")); len(globex) != 1 || !strings.Contains(globex[0], `"bob"`) {
+		t.Errorf("unexpected globex audit file: %v", globex)
+	}
+
+	quarantine := readLines(t, filepath.Join(tenants, QuarantineTenant, "audit.log"))
+	if len(quarantine) != 2 ||
+		!strings.Contains(quarantine[0], `"quarantine_reason":"missing tenant_id"`) ||
+		!strings.Contains(quarantine[1], `"quarantine_reason":"invalid tenant_id"`) {
+		t.Errorf("unexpected quarantine file: %v", quarantine)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit.log")); err == nil {
+		if data, _ := os.ReadFile(filepath.Join(dir, "audit.log")); len(data) > 0 {
+			t.Errorf("routed entries must not reach the shared audit file: %s", data)
+		}
+	}
+
+	for _, tenant := range []string{"acme", "globex", QuarantineTenant} {
+		report, err := VerifyChain(filepath.Join(tenants, tenant), "audit.log", nil)
+		if err != nil || report.Break != nil {
+			t.Errorf("%s: unexpected chain report %+v %v", tenant, report, err)
+		}
+	}
+}
+
+func TestTenantRouting_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	closed := make(map[string]int)
+	l, err := New("test", "info", WithLogDir(dir), WithTenantRouting(TenantConfig{
+		Streams: []Stream{StreamAudit},
+		MaxOpen: 2,
+		SinkFactory: func(tenant string, stream Stream) (Sink, error) {
+			return &closingSink{onClose: func() {
+				mu.Lock()
+				defer mu.Unlock()
+				closed[tenant]++
+			}}, nil
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		for _, tenant := range []string{"a", "b", "c"} {
+			l.LogAudit(tenantCtx(tenant), "resource_read", "agent", "read", tenant)
+		}
+	}
+	l.tenants.mu.Lock()
+	n := len(l.tenants.files)
+	l.tenants.mu.Unlock()
+	if n != 2 {
+		t.Errorf("expected 2 open tenant files, got %d", n)
+	}
+	mu.Lock()
+	if closed["a"] != 3 || closed["b"] != 2 || closed["c"] != 2 {
+		t.Errorf("unexpected sink closes: %v", closed)
+	}
+	mu.Unlock()
+	_ = l.Sync()
+
+	// Every reopen resumed the chain of its tenant.
+	for _, tenant := range []string{"a", "b", "c"} {
+		report, err := VerifyChain(filepath.Join(dir, "tenants", tenant), "audit.log", nil)
+		if err != nil || report.Break != nil || report.Entries != 3 || report.LastSeq != 3 {
+			t.Errorf("%s: unexpected chain report %+v %v", tenant, report, err)
+		}
+	}
+}
+
+func TestTenantRouting_PerTenantPolicyAndRotate(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("test", "info", WithLogDir(dir), WithTenantRouting(TenantConfig{
+		Default:  &RotationPolicy{MaxSizeMB: 5, MaxBackups: 1},
+		Policies: map[string]RotationPolicy{"acme": {MaxSizeMB: 1, MaxBackups: 3, MaxAgeDays: 7}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.LogSecurityEvent(tenantCtx("acme"), ports.SecurityEvent{Event: "auth_failed", Severity: ports.SeverityHigh})
+	l.LogSecurityEvent(tenantCtx("globex"), ports.SecurityEvent{Event: "auth_failed"})
+
+	acme, err := l.tenants.open("acme", StreamSecurity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acme.file.MaxSize != 1 || acme.file.MaxBackups != 3 || acme.file.MaxAge != 7 || acme.file.Compress {
+		t.Errorf("acme policy not applied: %+v", acme.file)
+	}
+	globex, err := l.tenants.open("globex", StreamSecurity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if globex.file.MaxSize != 5 || globex.file.MaxBackups != 1 {
+		t.Errorf("default policy not applied: %+v", globex.file)
+	}
+
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := StreamFiles(filepath.Join(dir, "tenants", "acme"), "security.log")
+	if err != nil || len(files) != 2 {
+		t.Errorf("expected Rotate to cover tenant files, got %v %v", files, err)
+	}
+}
+
+func TestTenantRouting_KeepsTenantsOffSharedSinks(t *testing.T) {
+	dir := t.TempDir()
+	shared, cef, own := &memorySink{}, &memorySink{}, &memorySink{}
+	l, err := New("test", "info", WithLogDir(dir),
+		WithSink(shared, StreamAudit),
+		WithSinkFormat(cef, FormatCEF, StreamAudit),
+		WithTenantRouting(TenantConfig{
+			Streams:     []Stream{StreamAudit},
+			SinkFactory: func(string, Stream) (Sink, error) { return own, nil },
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.LogAudit(tenantCtx("acme"), "resource_read", "alice", "read", "scan-1")
+	l.LogAudit(context.Background(), "resource_read", "carol", "read", "scan-2")
+	_ = l.Sync()
+
+	if len(own.lines) != 1 || !strings.Contains(own.lines[0], `"alice"`) {
+		t.Errorf("tenant sink should receive the tenant entry: %v", own.lines)
+	}
+	for name, sink := range map[string]*memorySink{"shared": shared, "cef": cef} {
+		if len(sink.lines) != 1 || strings.Contains(sink.lines[0], "alice") || !strings.Contains(sink.lines[0], "carol") {
+			t.Errorf("%s sink should only receive the quarantined entry: %v", name, sink.lines)
+		}
+	}
+
+	_, err = New("test", "info", WithLogDir(t.TempDir()), WithTenantRouting(TenantConfig{Streams: []Stream{StreamApp}}))
+	if err == nil {
+		t.Error("expected routing the app stream to be rejected")
+	}
+}
+
+func TestTenantRouting_OpensEachFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	created := make(map[string]int)
+	l, err := New("test", "info", WithLogDir(dir), WithTenantRouting(TenantConfig{
+		SinkFactory: func(tenant string, stream Stream) (Sink, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			created[tenant+"/"+string(stream)]++
+			return nil, nil
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.LogAudit(tenantCtx("acme"), "resource_read", "alice", "read", "scan-1")
+		}()
+	}
+	wg.Wait()
+	_ = l.Sync()
+
+	if created["acme/audit"] != 1 {
+		t.Errorf("expected the tenant file to be created once, got %v", created)
+	}
+	report, err := VerifyChain(filepath.Join(dir, "tenants", "acme"), "audit.log", nil)
+	if err != nil || report.Break != nil || report.Entries != 16 {
+		t.Errorf("unexpected chain report %+v %v", report, err)
+	}
+}
+
+func TestTenantRouting_ReopenWaitsForEviction(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		mu      sync.Mutex
+		live    = make(map[string]int)
+		forked  bool
+		once    sync.Once
+		closing = make(chan struct{})
+		gate    = make(chan struct{})
+	)
+	l, err := New("test", "info", WithLogDir(dir), WithTenantRouting(TenantConfig{
+		Streams: []Stream{StreamAudit},
+		MaxOpen: 1,
+		SinkFactory: func(tenant string, stream Stream) (Sink, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if live[tenant] > 0 {
+				forked = true
+			}
+			live[tenant]++
+			return &closingSink{onClose: func() {
+				if tenant == "a" {
+					// Hold the first eviction of a while it is reopened.
+					once.Do(func() {
+						close(closing)
+						<-gate
+					})
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				live[tenant]--
+			}}, nil
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.LogAudit(tenantCtx("a"), "resource_read", "agent", "read", "a")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		l.LogAudit(tenantCtx("b"), "resource_read", "agent", "read", "b")
+	}()
+	<-closing
+	go func() {
+		defer wg.Done()
+		l.LogAudit(tenantCtx("a"), "resource_read", "agent", "read", "a")
+	}()
+	// Give the reopen time to create a second handle if it did not wait.
+	time.Sleep(10 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	_ = l.Sync()
+
+	mu.Lock()
+	if forked {
+		t.Error("tenant a was reopened before its evicted handle was closed")
+	}
+	mu.Unlock()
+	report, err := VerifyChain(filepath.Join(dir, "tenants", "a"), "audit.log", nil)
+	if err != nil || report.Break != nil || report.Entries != 2 || report.LastSeq != 2 {
+		t.Errorf("unexpected chain report %+v %v", report, err)
+	}
+}
+
+type closingSink struct {
+	memorySink
+	onClose func()
+}
+
+func (s *closingSink) Close() error {
+	s.onClose()
+	return nil
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ChainBreak describes the first entry whose link could not be verified.
+type ChainBreak struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (b *ChainBreak) Error() string {
+	return fmt.Sprintf("audit chain broken at %s:%d (seq %d): %s", b.File, b.Line, b.Seq, b.Reason)
+}
+
+// ChainReport summarizes the verification of a hash-chained stream.
+type ChainReport struct {
+	Files    []string
+	Entries  int
+	FirstSeq uint64
+	LastSeq  uint64
+
+	// Break is nil when every link verified.
+	Break *ChainBreak
+	// Restarts lists the unparsable entries stepped over, either by a
+	// restart marker or as legacy entries before the chain began. Each
+	// ChainBreak points at the first entry skipped.
+	Restarts []ChainBreak
+}
+
+// VerifyAuditChain verifies the audit stream written by a Logger in logDir.
+func VerifyAuditChain(logDir string, key []byte) (*ChainReport, error) {
+	return VerifyChain(logDir, "audit.log", key)
+}
+
+// VerifyChain walks every file of a chained stream, oldest first, and reports
+// the first missing, reordered or modified entry. The oldest surviving entry is
+// trusted as the anchor when earlier backups have already been pruned.
+func VerifyChain(logDir string, filename string, key []byte) (*ChainReport, error) {
+	files, err := StreamFiles(logDir, filename)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyChainFiles(files, key)
+}
+
+// VerifyChainFiles verifies the given stream files in the order provided.
+func VerifyChainFiles(files []string, key []byte) (*ChainReport, error) {
+	report := &ChainReport{Files: files}
+	var prev chainLink
+	// skipped is the first unparsable entry not yet accounted for and
+	// skippedCount the number of such entries since the last link.
+	var skipped *ChainBreak
+	skippedCount := 0
+
+	for _, file := range files {
+		err := eachLine(file, func(lineNo int, line []byte) error {
+			body, link, err := parseChainLink(line)
+			if err != nil {
+				reason := err.Error()
+				if errors.Is(err, errNotChained) {
+					reason = "missing chain fields"
+				}
+				if skipped == nil {
+					skipped = &ChainBreak{File: file, Line: lineNo, Seq: prev.Seq + 1, Reason: reason}
+				}
+				skippedCount++
+				return nil
+			}
+
+			if chainHash(key, body) != link.Hash {
+				return &ChainBreak{File: file, Line: lineNo, Seq: link.Seq, Reason: "entry hash mismatch (modified entry or wrong key)"}
+			}
+
+			if skipped != nil {
+				// Only a restart marker accounting for every skipped entry,
+				// or a chain starting at genesis after legacy entries, may
+				// follow unparsable entries.
+				if report.Entries == 0 {
+					if link.Seq != 1 {
+						return skipped
+					}
+					skipped.Reason = fmt.Sprintf("%d unchained entries before the chain start", skippedCount)
+				} else {
+					if link.Restart != skippedCount || link.Seq != prev.Seq+1 || link.Prev != prev.Hash {
+						return skipped
+					}
+					skipped.Reason = fmt.Sprintf("%d unparsable entries stepped over by a restart marker", skippedCount)
+				}
+				report.Restarts = append(report.Restarts, *skipped)
+				skipped, skippedCount = nil, 0
+			} else if link.Restart != 0 && report.Entries > 0 {
+				return &ChainBreak{File: file, Line: lineNo, Seq: link.Seq, Reason: "restart marker without skipped entries"}
+			}
+
+			if report.Entries == 0 {
+				if link.Seq == 1 && link.Prev != genesisHash {
+					return &ChainBreak{File: file, Line: lineNo, Seq: link.Seq, Reason: "first entry does not link to genesis"}
+				}
+				report.FirstSeq = link.Seq
+			} else {
+				if link.Seq != prev.Seq+1 {
+					return &ChainBreak{
+						File:   file,
+						Line:   lineNo,
+						Seq:    prev.Seq + 1,
+						Reason: fmt.Sprintf("missing link: expected seq %d, found %d", prev.Seq+1, link.Seq),
+					}
+				}
+				if link.Prev != prev.Hash {
+					return &ChainBreak{File: file, Line: lineNo, Seq: link.Seq, Reason: "previous hash mismatch"}
+				}
+			}
+
+			prev = link
+			report.Entries++
+			report.LastSeq = link.Seq
+			return nil
+		})
+
+		var brk *ChainBreak
+		if errors.As(err, &brk) {
+			report.Break = brk
+			return report, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.Break = skipped
+	return report, nil
+}
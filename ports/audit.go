@@ -0,0 +1,99 @@
+package ports
+
+// ActorKind classifies who performed an audited action.
+type ActorKind string
+
+const (
+	ActorUser    ActorKind = "user"
+	ActorAgent   ActorKind = "agent"
+	ActorService ActorKind = "service"
+)
+
+// AuditAction is the verb of an audited action.
+type AuditAction string
+
+const (
+	ActionCreate    AuditAction = "create"
+	ActionRead      AuditAction = "read"
+	ActionUpdate    AuditAction = "update"
+	ActionDelete    AuditAction = "delete"
+	ActionExecute   AuditAction = "execute"
+	ActionApprove   AuditAction = "approve"
+	ActionReject    AuditAction = "reject"
+	ActionLogin     AuditAction = "login"
+	ActionLogout    AuditAction = "logout"
+	ActionGrant     AuditAction = "grant"
+	ActionRevoke    AuditAction = "revoke"
+	ActionConfigure AuditAction = "configure"
+	ActionExport    AuditAction = "export"
+)
+
+// AuditActions lists every known AuditAction, e.g. for UI filters.
+func AuditActions() []AuditAction {
+	return []AuditAction{
+		ActionCreate, ActionRead, ActionUpdate, ActionDelete,
+		ActionExecute, ActionApprove, ActionReject,
+		ActionLogin, ActionLogout, ActionGrant, ActionRevoke,
+		ActionConfigure, ActionExport,
+	}
+}
+
+// Valid reports whether a is one of the known actions.
+func (a AuditAction) Valid() bool {
+	for _, known := range AuditActions() {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditOutcome is the result of an audited action.
+type AuditOutcome string
+
+const (
+	OutcomeSuccess AuditOutcome = "success"
+	OutcomeFailure AuditOutcome = "failure"
+	OutcomeDenied  AuditOutcome = "denied"
+)
+
+// AuditActor identifies who performed the action.
+type AuditActor struct {
+	ID   string    `json:"id"`
+	Kind ActorKind `json:"kind,omitempty"`
+	IP   string    `json:"ip,omitempty"`
+}
+
+// AuditResource identifies what the action was performed on.
+type AuditResource struct {
+	Type string `json:"type,omitempty"`
+	ID   string `json:"id"`
+}
+
+// AuditChange records a single field transition caused by the action.
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditRequest carries metadata of the request that triggered the action.
+type AuditRequest struct {
+	ID        string `json:"id,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// AuditEvent is the structured record of an important system action.
+type AuditEvent struct {
+	Event    string        `json:"event"`
+	Actor    AuditActor    `json:"actor"`
+	Action   AuditAction   `json:"action"`
+	Resource AuditResource `json:"resource"`
+	Outcome  AuditOutcome  `json:"outcome"`
+	Reason   string        `json:"reason,omitempty"`
+	Changes  []AuditChange `json:"changes,omitempty"`
+	Request  *AuditRequest `json:"request,omitempty"`
+	Fields   []Field       `json:"-"`
+}
@@ -16,6 +16,10 @@ type Logger interface {
 	Debug(ctx context.Context, event string, msg string, fields ...Field)
 	Info(ctx context.Context, event string, msg string, fields ...Field)
 	ErrorErr(ctx context.Context, event string, err error, msg string, fields ...Field)
+	LogAuditEvent(ctx context.Context, event AuditEvent)
+	// Deprecated: use LogAuditEvent. Kept as a shim over LogAuditEvent for existing callers.
 	LogAudit(ctx context.Context, event string, actor string, action string, resource string, fields ...Field)
+	LogSecurityEvent(ctx context.Context, event SecurityEvent)
+	// Deprecated: use LogSecurityEvent. Kept as a shim over LogSecurityEvent for existing callers.
 	LogSecurity(ctx context.Context, event string, ip string, reason string, fields ...Field)
 }
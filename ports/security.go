@@ -0,0 +1,73 @@
+package ports
+
+// SecuritySeverity ranks how urgently a security event needs attention.
+type SecuritySeverity string
+
+const (
+	SeverityInfo     SecuritySeverity = "info"
+	SeverityLow      SecuritySeverity = "low"
+	SeverityMedium   SecuritySeverity = "medium"
+	SeverityHigh     SecuritySeverity = "high"
+	SeverityCritical SecuritySeverity = "critical"
+)
+
+// Score maps the severity onto the 0-10 scale used by SIEM formats.
+// Unknown severities score as medium.
+func (s SecuritySeverity) Score() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityLow:
+		return 3
+	case SeverityHigh:
+		return 8
+	case SeverityCritical:
+		return 10
+	default:
+		return 5
+	}
+}
+
+// SecurityCategory groups security events, following the ECS event categories.
+type SecurityCategory string
+
+const (
+	CategoryAuthentication     SecurityCategory = "authentication"
+	CategoryIAM                SecurityCategory = "iam"
+	CategoryNetwork            SecurityCategory = "network"
+	CategoryIntrusionDetection SecurityCategory = "intrusion_detection"
+	CategoryMalware            SecurityCategory = "malware"
+	CategoryVulnerability      SecurityCategory = "vulnerability"
+	CategoryConfiguration      SecurityCategory = "configuration"
+	CategoryWeb                SecurityCategory = "web"
+)
+
+// SecurityEndpoint is the source or destination of a security event.
+type SecurityEndpoint struct {
+	IP       string `json:"ip,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+// IsZero reports whether no attribute of the endpoint is set.
+func (e SecurityEndpoint) IsZero() bool {
+	return e == SecurityEndpoint{}
+}
+
+// SecurityEvent is the structured record of a security-relevant occurrence,
+// shaped so it can be exported to SIEMs without per-customer mapping.
+type SecurityEvent struct {
+	Event       string           `json:"event"`
+	Severity    SecuritySeverity `json:"severity"`
+	Category    SecurityCategory `json:"category"`
+	Outcome     AuditOutcome     `json:"outcome,omitempty"`
+	Source      SecurityEndpoint `json:"source"`
+	Destination SecurityEndpoint `json:"destination,omitempty"`
+	// Technique is a MITRE ATT&CK technique ID, e.g. "T1110" or "T1110.001".
+	Technique string `json:"technique,omitempty"`
+	// RuleID identifies the detection rule that raised the event.
+	RuleID string  `json:"rule_id,omitempty"`
+	Reason string  `json:"reason,omitempty"`
+	Fields []Field `json:"-"`
+}